@@ -81,6 +81,87 @@ func Move(obj AABB, x, y float64) *AABBData {
 	}
 }
 
+func (aabb *AABBData) Contains(other *AABBData) bool {
+	return Contains(aabb, other)
+}
+
+// Contains returns whether a wholly contains b.
+func Contains(a, b AABB) bool {
+	aData := a.AABB()
+	bData := b.AABB()
+	return aData.MinX <= bData.MinX &&
+		aData.MinY <= bData.MinY &&
+		aData.MaxX >= bData.MaxX &&
+		aData.MaxY >= bData.MaxY
+}
+
+func (aabb *AABBData) Expand(margin float64) *AABBData {
+	return Expand(aabb, margin)
+}
+
+// Expand returns a copy of the AABB grown by margin on every side.
+func Expand(obj AABB, margin float64) *AABBData {
+	data := obj.AABB()
+	return &AABBData{
+		MinX: data.MinX - margin,
+		MinY: data.MinY - margin,
+		MaxX: data.MaxX + margin,
+		MaxY: data.MaxY + margin,
+	}
+}
+
+func (aabb *AABBData) RayCast(x, y, dx, dy, maxFraction float64) (float64, bool) {
+	return RayCast(aabb, x, y, dx, dy, maxFraction)
+}
+
+// RayCast tests the ray starting at x, y and moving along dx, dy against the AABB, using the slab method. Points along the
+// ray are given as fractions of dx, dy, and only the part of the ray from 0 up to maxFraction is tested. It returns the
+// fraction at which the ray enters the AABB (0 if it starts inside of it), and whether it hits the AABB at all. Rays that
+// only touch the AABB's edges hit it.
+func RayCast(obj AABB, x, y, dx, dy, maxFraction float64) (float64, bool) {
+	data := obj.AABB()
+
+	enter, exit := 0.0, maxFraction
+	if !raySlab(x, dx, data.MinX, data.MaxX, &enter, &exit) || !raySlab(y, dy, data.MinY, data.MaxY, &enter, &exit) {
+		return 0, false
+	}
+	return enter, true
+}
+
+// raySlab clips the enter and exit fractions of a ray to the slab between min and max along a single axis, returning
+// false if nothing is left of the ray.
+func raySlab(origin, delta, min, max float64, enter, exit *float64) bool {
+	if delta == 0 {
+		return origin >= min && origin <= max
+	}
+
+	t1 := (min - origin) / delta
+	t2 := (max - origin) / delta
+	if t1 > t2 {
+		t1, t2 = t2, t1
+	}
+
+	*enter = math.Max(*enter, t1)
+	*exit = math.Min(*exit, t2)
+	return *enter <= *exit
+}
+
+func (aabb *AABBData) DistanceToPoint(x, y float64) float64 {
+	return DistanceToPoint(aabb, x, y)
+}
+
+// DistanceToPoint returns the distance from the point to the closest point of the AABB; 0 if the point is inside of it.
+func DistanceToPoint(obj AABB, x, y float64) float64 {
+	data := obj.AABB()
+	dx := math.Max(math.Max(data.MinX-x, x-data.MaxX), 0)
+	dy := math.Max(math.Max(data.MinY-y, y-data.MaxY), 0)
+	return math.Hypot(dx, dy)
+}
+
+func (aabb *AABBData) center() (float64, float64) {
+	return (aabb.MinX + aabb.MaxX) / 2, (aabb.MinY + aabb.MaxY) / 2
+}
+
 func (aabb *AABBData) IsValid() bool {
 	return aabb.MaxX > aabb.MinY && aabb.MaxY > aabb.MinY
 }
@@ -50,3 +50,24 @@ func TestMerge(t *testing.T) {
 		})
 	}
 }
+
+func TestDistanceToPoint(t *testing.T) {
+	box := &AABBData{0, 0, 10, 10}
+	tests := []struct {
+		name string
+		x, y float64
+		want float64
+	}{
+		{name: "Inside", x: 5, y: 5, want: 0},
+		{name: "On the edge", x: 10, y: 5, want: 0},
+		{name: "Beside", x: -5, y: 5, want: 5},
+		{name: "Past the corner", x: 13, y: 14, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DistanceToPoint(box, tt.x, tt.y); got != tt.want {
+				t.Errorf("DistanceToPoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package aabb
+
+// Broadphase is implemented by the structures that keep track of a set of objects by their AABBs, to quickly find the
+// ones that might be colliding; Tree, SpatialHash, and SweepAndPrune. Objects are told apart by identity, so they must be pointers.
+type Broadphase interface {
+	// TryInsert adds the object, returning ErrAlreadyInTree if it's already there, or ErrNotAReference if it isn't a
+	// pointer.
+	TryInsert(object AABB) error
+	// TryRemove removes the object, returning ErrtNotInTree if it isn't there.
+	TryRemove(object AABB) error
+	// TryUpdate refreshes the object's position after it has moved, returning whether the structure had to change, or
+	// ErrtNotInTree if the object isn't there.
+	TryUpdate(object AABB) (bool, error)
+	// QueryOverlapsFunc calls visit for each object whose AABB overlaps the object's AABB, not counting the object itself,
+	// until visit returns false.
+	QueryOverlapsFunc(object AABB, visit func(found AABB) bool)
+	// RayCast calls the callback for each object whose AABB is hit by the ray; see RayCastCallback.
+	RayCast(x, y, dx, dy, maxFraction float64, callback RayCastCallback)
+	// QueryAllPairs returns every pair of objects whose AABBs overlap, once each.
+	QueryAllPairs() []Pair
+}
+
+var (
+	_ Broadphase = (*Tree)(nil)
+	_ Broadphase = (*SpatialHash)(nil)
+	_ Broadphase = (*SweepAndPrune)(nil)
+)
@@ -0,0 +1,117 @@
+package aabb
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// DistanceFunc returns the exact distance from the point being queried to the object, and whether the object should be
+// considered at all, which allows filtering the objects. The distance must never be less than the distance from the
+// point to the object's AABB, as that's what's used to rule out the parts of the tree that can't hold anything closer.
+type DistanceFunc func(object AABB) (float64, bool)
+
+// Neighbour is an object found by a nearest-neighbour query, along with its distance from the point queried.
+type Neighbour struct {
+	Object   AABB
+	Distance float64
+}
+
+// Nearest returns the object in the tree that's nearest to the point x, y, as measured by the distance function, if
+// there is one within maxDist. If distance is nil, the distance to each object's AABB is used.
+func (tree *Tree) Nearest(x, y, maxDist float64, distance DistanceFunc) (Neighbour, bool) {
+	nearest := tree.KNearest(x, y, 1, maxDist, distance)
+	if len(nearest) == 0 {
+		return Neighbour{}, false
+	}
+	return nearest[0], true
+}
+
+// KNearest returns up to k objects in the tree that are nearest to the point x, y, as measured by the distance function,
+// and within maxDist, sorted by distance. If distance is nil, the distance to each object's AABB is used. It returns nil
+// if k isn't positive, or if the tree is empty.
+//
+// The tree is searched best-first: nodes are visited in order of the distance from the point to their AABBs, which is a
+// lower bound for the distance to any object within them, and the search stops as soon as that bound is further away
+// than the k nearest objects found so far.
+func (tree *Tree) KNearest(x, y float64, k int, maxDist float64, distance DistanceFunc) []Neighbour {
+	if tree.IsEmpty() || k <= 0 {
+		return nil
+	}
+
+	// k can be far larger than the tree, but there can't be more results than there are objects in it.
+	if len(tree.NodeIndexMap) < k {
+		k = len(tree.NodeIndexMap)
+	}
+	found := make([]Neighbour, 0, k)
+
+	if distance == nil {
+		distance = func(object AABB) (float64, bool) {
+			return DistanceToPoint(object, x, y), true
+		}
+	}
+
+	queue := &nodeQueue{}
+	queue.push(tree.Root, x, y)
+
+	for queue.Len() > 0 {
+		next := heap.Pop(queue).(queuedNode)
+
+		if next.bound > maxDist || (len(found) == k && next.bound >= found[k-1].Distance) {
+			break
+		}
+
+		if !next.node.IsLeaf() {
+			queue.push(next.node.Left, x, y)
+			queue.push(next.node.Right, x, y)
+			continue
+		}
+
+		dist, ok := distance(next.node.Object)
+		if !ok || dist > maxDist || (len(found) == k && dist >= found[k-1].Distance) {
+			continue
+		}
+
+		// Insert the object into the sorted results, dropping the furthest one if there are too many.
+		i := sort.Search(len(found), func(i int) bool { return found[i].Distance > dist })
+		if len(found) < k {
+			found = append(found, Neighbour{})
+		}
+		copy(found[i+1:], found[i:])
+		found[i] = Neighbour{next.node.Object, dist}
+	}
+
+	return found
+}
+
+type queuedNode struct {
+	node  *treeNode
+	bound float64
+}
+
+// nodeQueue is a priority queue of nodes, closest first, for use with container/heap.
+type nodeQueue []queuedNode
+
+// push adds the node to the queue. Leaves are queued by the distance to their objects' AABBs rather than their fat AABBs,
+// as that's a tighter bound.
+func (q *nodeQueue) push(node *treeNode, x, y float64) {
+	bounds := node.AABB()
+	if node.IsLeaf() {
+		bounds = node.Object.AABB()
+	}
+	heap.Push(q, queuedNode{node, DistanceToPoint(bounds, x, y)})
+}
+
+func (q nodeQueue) Len() int           { return len(q) }
+func (q nodeQueue) Less(i, j int) bool { return q[i].bound < q[j].bound }
+func (q nodeQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *nodeQueue) Push(x interface{}) {
+	*q = append(*q, x.(queuedNode))
+}
+
+func (q *nodeQueue) Pop() interface{} {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
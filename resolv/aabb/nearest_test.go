@@ -0,0 +1,100 @@
+package aabb_test
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv/aabb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAABBTree_KNearest(t *testing.T) {
+	tree := NewTree()
+	tree.Margin = 1
+	objects := make([]*AABBData, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		object := (&AABBData{0, 0, 1, 1}).Move(rand.Float64()*100, rand.Float64()*100)
+		objects = append(objects, object)
+		tree.Insert(object)
+	}
+
+	// The distance to the center of each object, skipping those in the left half of the world.
+	centerDistance := func(x, y float64) DistanceFunc {
+		return func(object AABB) (float64, bool) {
+			data := object.AABB()
+			if data.MinX < 50 {
+				return 0, false
+			}
+			return math.Hypot((data.MinX+data.MaxX)/2-x, (data.MinY+data.MaxY)/2-y), true
+		}
+	}
+
+	bruteForce := func(x, y, maxDist float64, distance DistanceFunc) []Neighbour {
+		all := []Neighbour{}
+		for _, object := range objects {
+			if dist, ok := distance(object); ok && dist <= maxDist {
+				all = append(all, Neighbour{Object: object, Distance: dist})
+			}
+		}
+		sort.Slice(all, func(i, j int) bool { return all[i].Distance < all[j].Distance })
+		return all
+	}
+
+	t.Run("Nearest", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			x, y := rand.Float64()*100, rand.Float64()*100
+			distance := centerDistance(x, y)
+
+			expected := bruteForce(x, y, math.Inf(1), distance)
+			nearest, ok := tree.Nearest(x, y, math.Inf(1), distance)
+			assert.True(t, ok)
+			assert.Equal(t, expected[0], nearest)
+		}
+	})
+
+	t.Run("K nearest", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			x, y := rand.Float64()*100, rand.Float64()*100
+			distance := centerDistance(x, y)
+
+			expected := bruteForce(x, y, 20, distance)
+			if len(expected) > 10 {
+				expected = expected[:10]
+			}
+			assert.Equal(t, expected, tree.KNearest(x, y, 10, 20, distance))
+		}
+	})
+
+	t.Run("Any k", func(t *testing.T) {
+		assert.Nil(t, tree.KNearest(50, 50, 0, math.Inf(1), nil))
+		assert.Nil(t, tree.KNearest(50, 50, -1, math.Inf(1), nil))
+		assert.Nil(t, NewTree().KNearest(50, 50, 1, math.Inf(1), nil))
+
+		// A huge k doesn't allocate room for more results than there are objects.
+		all := tree.KNearest(50, 50, int(^uint(0)>>1), math.Inf(1), nil)
+		assert.Len(t, all, len(objects))
+		assert.Equal(t, len(objects), cap(all))
+	})
+
+	t.Run("AABB distance", func(t *testing.T) {
+		neighbours := tree.KNearest(50, 50, 5, math.Inf(1), nil)
+		assert.Len(t, neighbours, 5)
+		for i, neighbour := range neighbours {
+			assert.Equal(t, neighbour.Object.AABB().DistanceToPoint(50, 50), neighbour.Distance)
+			if i > 0 {
+				assert.GreaterOrEqual(t, neighbour.Distance, neighbours[i-1].Distance)
+			}
+		}
+	})
+
+	t.Run("Nothing in range", func(t *testing.T) {
+		_, ok := tree.Nearest(-1000, -1000, 10, nil)
+		assert.False(t, ok)
+		assert.Empty(t, tree.KNearest(-1000, -1000, 3, 10, nil))
+
+		_, ok = NewTree().Nearest(0, 0, math.Inf(1), nil)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,5 @@
+// +build !race
+
+package aabb_test
+
+const raceEnabled = false
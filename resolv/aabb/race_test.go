@@ -0,0 +1,7 @@
+// +build race
+
+package aabb_test
+
+// raceEnabled reports whether the tests are run with the race detector, which makes sync.Pool drop some of the values put
+// into it, so that reusing them can't be relied upon to avoid allocations.
+const raceEnabled = true
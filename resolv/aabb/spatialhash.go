@@ -0,0 +1,413 @@
+package aabb
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrInvalidCellSize is what NewSpatialHash panics with if the cell size isn't a finite number greater than 0.
+var ErrInvalidCellSize = errors.New("The cell size must be a finite number greater than 0")
+
+// SpatialHash is a uniform grid of square cells, each holding the objects whose AABBs touch it. Only the cells that
+// hold objects are stored, so the grid has no bounds. Queries only need to look at the cells they cover, which makes it a
+// good alternative to a Tree when there are many objects of about the same size as the cells (bullets, particles,
+// tiles); objects much larger than the cells are slow to insert and update, as they're held by many cells.
+type SpatialHash struct {
+	// CellSize is the width and height of each cell. It can't be changed once objects have been inserted.
+	CellSize float64
+
+	cells   map[cellKey][]AABB
+	entries map[AABB]*cellRange
+
+	// occupied covers every cell that holds objects, and possibly some that no longer do; it grows as objects are inserted
+	// and moved, and starts over from the only object left whenever there's just one.
+	occupied cellRange
+}
+
+type cellKey struct {
+	X, Y int
+}
+
+// cellRange is the range of cells touched by an AABB, inclusive.
+type cellRange struct {
+	MinX, MinY, MaxX, MaxY int
+}
+
+func (cells cellRange) contains(x, y int) bool {
+	return x >= cells.MinX && x <= cells.MaxX && y >= cells.MinY && y <= cells.MaxY
+}
+
+// NewSpatialHash returns a new, empty SpatialHash with cells of the size provided. It panics with ErrInvalidCellSize if
+// the cell size isn't a finite number greater than 0.
+func NewSpatialHash(cellSize float64) *SpatialHash {
+	if !(cellSize > 0) || math.IsInf(cellSize, 1) {
+		panic(ErrInvalidCellSize)
+	}
+	return &SpatialHash{
+		CellSize: cellSize,
+		cells:    make(map[cellKey][]AABB),
+		entries:  make(map[AABB]*cellRange),
+	}
+}
+
+// Len returns the number of objects in the SpatialHash.
+func (hash *SpatialHash) Len() int {
+	return len(hash.entries)
+}
+
+func (hash *SpatialHash) cellsOf(obj AABB) cellRange {
+	data := obj.AABB()
+	return cellRange{
+		MinX: hash.cellIndex(data.MinX),
+		MinY: hash.cellIndex(data.MinY),
+		MaxX: hash.cellIndex(data.MaxX),
+		MaxY: hash.cellIndex(data.MaxY),
+	}
+}
+
+// maxCellIndex is the furthest a cell can be from the origin; coordinates further out than that are put in the last
+// cell, so that the indices can't overflow.
+const maxCellIndex = 1 << 30
+
+// cellIndex returns the index of the cell that the coordinate lies in.
+func (hash *SpatialHash) cellIndex(v float64) int {
+	return int(math.Max(-maxCellIndex, math.Min(maxCellIndex, math.Floor(v/hash.CellSize))))
+}
+
+// Insert adds the object to the SpatialHash. It panics if the object is already there, or isn't a pointer; see TryInsert.
+func (hash *SpatialHash) Insert(object AABB) {
+	if err := hash.TryInsert(object); err != nil {
+		panic(err)
+	}
+}
+
+// TryInsert adds the object to the SpatialHash, like Insert, but returns ErrAlreadyInTree or ErrNotAReference instead of
+// panicking.
+func (hash *SpatialHash) TryInsert(object AABB) error {
+	if err := checkReference(object); err != nil {
+		return err
+	}
+	if hash.entries[object] != nil {
+		return ErrAlreadyInTree
+	}
+
+	cells := hash.cellsOf(object)
+	hash.entries[object] = &cells
+	hash.addToCells(object, cells)
+	hash.occupy(cells)
+	return nil
+}
+
+// Remove removes the object from the SpatialHash. It panics if the object isn't there; see TryRemove.
+func (hash *SpatialHash) Remove(object AABB) {
+	if err := hash.TryRemove(object); err != nil {
+		panic(err)
+	}
+}
+
+// TryRemove removes the object from the SpatialHash, like Remove, but returns ErrtNotInTree instead of panicking.
+func (hash *SpatialHash) TryRemove(object AABB) error {
+	cells, ok := hash.entries[object]
+	if !ok {
+		return ErrtNotInTree
+	}
+	hash.removeFromCells(object, *cells)
+	delete(hash.entries, object)
+	return nil
+}
+
+// Update refreshes the position of the object in the SpatialHash, after it has moved. It returns whether the object
+// moved into different cells, and panics with ErrtNotInTree if the object isn't in the SpatialHash; see TryUpdate.
+func (hash *SpatialHash) Update(object AABB) bool {
+	updated, err := hash.TryUpdate(object)
+	if err != nil {
+		panic(err)
+	}
+	return updated
+}
+
+// TryUpdate refreshes the position of the object in the SpatialHash, like Update, but returns ErrtNotInTree instead of
+// panicking.
+func (hash *SpatialHash) TryUpdate(object AABB) (bool, error) {
+	cells, ok := hash.entries[object]
+	if !ok {
+		return false, ErrtNotInTree
+	}
+
+	current := hash.cellsOf(object)
+	if current == *cells {
+		return false, nil
+	}
+
+	hash.removeFromCells(object, *cells)
+	hash.addToCells(object, current)
+	*cells = current
+	hash.occupy(current)
+	return true, nil
+}
+
+// occupy grows the range of occupied cells to cover the cells provided, which must be those of an object that's already
+// in the SpatialHash.
+func (hash *SpatialHash) occupy(cells cellRange) {
+	if len(hash.entries) == 1 {
+		hash.occupied = cells
+		return
+	}
+	if cells.MinX < hash.occupied.MinX {
+		hash.occupied.MinX = cells.MinX
+	}
+	if cells.MinY < hash.occupied.MinY {
+		hash.occupied.MinY = cells.MinY
+	}
+	if cells.MaxX > hash.occupied.MaxX {
+		hash.occupied.MaxX = cells.MaxX
+	}
+	if cells.MaxY > hash.occupied.MaxY {
+		hash.occupied.MaxY = cells.MaxY
+	}
+}
+
+func (hash *SpatialHash) addToCells(object AABB, cells cellRange) {
+	for y := cells.MinY; y <= cells.MaxY; y++ {
+		for x := cells.MinX; x <= cells.MaxX; x++ {
+			key := cellKey{x, y}
+			hash.cells[key] = append(hash.cells[key], object)
+		}
+	}
+}
+
+func (hash *SpatialHash) removeFromCells(object AABB, cells cellRange) {
+	for y := cells.MinY; y <= cells.MaxY; y++ {
+		for x := cells.MinX; x <= cells.MaxX; x++ {
+			key := cellKey{x, y}
+			objects := hash.cells[key]
+			for i, other := range objects {
+				if other == object {
+					last := len(objects) - 1
+					objects[i] = objects[last]
+					objects[last] = nil
+					objects = objects[:last]
+					break
+				}
+			}
+			if len(objects) == 0 {
+				delete(hash.cells, key)
+			} else {
+				hash.cells[key] = objects
+			}
+		}
+	}
+}
+
+// QueryOverlaps returns the objects in the SpatialHash whose AABBs overlap the object's AABB, not counting the object
+// itself.
+func (hash *SpatialHash) QueryOverlaps(object AABB) []AABB {
+	return hash.QueryOverlapsInto(make([]AABB, 0), object)
+}
+
+// QueryOverlapsInto appends the objects in the SpatialHash whose AABBs overlap the object's AABB, not counting the object
+// itself, to dst, and returns the extended slice.
+func (hash *SpatialHash) QueryOverlapsInto(dst []AABB, object AABB) []AABB {
+	hash.QueryOverlapsFunc(object, func(found AABB) bool {
+		dst = append(dst, found)
+		return true
+	})
+	return dst
+}
+
+// QueryOverlapsFunc calls visit for each object in the SpatialHash whose AABB overlaps the object's AABB, not counting the
+// object itself, until visit returns false. If the object's AABB covers more cells than the SpatialHash holds, the cells
+// held are looked through instead of the cells covered, so that large queries don't have to visit lots of empty cells;
+// the objects are then visited in no particular order.
+func (hash *SpatialHash) QueryOverlapsFunc(object AABB, visit func(found AABB) bool) {
+	testAABB := object.AABB()
+	query := hash.cellsOf(testAABB)
+
+	// visitCell visits the objects in the cell, returning false once visit does.
+	visitCell := func(key cellKey, objects []AABB) bool {
+		for _, found := range objects {
+			// Objects that span several cells are only reported from the first of them that the query covers.
+			cells := hash.entries[found]
+			if key.X != maxInt(cells.MinX, query.MinX) || key.Y != maxInt(cells.MinY, query.MinY) {
+				continue
+			}
+			if found != object && Overlaps(found, testAABB) && !visit(found) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// The number of cells covered is worked out with floats, as it can be too large for an int.
+	covered := (float64(query.MaxX) - float64(query.MinX) + 1) * (float64(query.MaxY) - float64(query.MinY) + 1)
+
+	if covered > float64(len(hash.cells)) {
+		for key, objects := range hash.cells {
+			if key.X < query.MinX || key.X > query.MaxX || key.Y < query.MinY || key.Y > query.MaxY {
+				continue
+			}
+			if !visitCell(key, objects) {
+				return
+			}
+		}
+		return
+	}
+
+	for y := query.MinY; y <= query.MaxY; y++ {
+		for x := query.MinX; x <= query.MaxX; x++ {
+			key := cellKey{x, y}
+			if !visitCell(key, hash.cells[key]) {
+				return
+			}
+		}
+	}
+}
+
+// RayCast casts a ray starting at x, y and moving along dx, dy through the SpatialHash, calling the callback for each
+// object whose AABB the ray hits between the fractions 0 and maxFraction of dx, dy; see Tree.RayCast. The ray is cut down
+// to the cells that hold objects, which are then walked in the order the ray crosses them, so clipping the ray skips the
+// cells past the nearest hit. If the ray crosses more cells than the SpatialHash holds, the cells held are looked through
+// instead, and the objects are visited in no particular order. Rays that aren't finite don't hit anything.
+func (hash *SpatialHash) RayCast(x, y, dx, dy, maxFraction float64, callback RayCastCallback) {
+	if len(hash.entries) == 0 || !(maxFraction > 0) || !isFinite(x) || !isFinite(y) || !isFinite(dx) || !isFinite(dy) ||
+		!isFinite(maxFraction) {
+		return
+	}
+
+	// Cut the ray down to the part that crosses the occupied cells.
+	occupied := hash.occupied
+	enter, exit := 0.0, maxFraction
+	if !raySlab(x, dx, float64(occupied.MinX)*hash.CellSize, float64(occupied.MaxX+1)*hash.CellSize, &enter, &exit) ||
+		!raySlab(y, dy, float64(occupied.MinY)*hash.CellSize, float64(occupied.MaxY+1)*hash.CellSize, &enter, &exit) {
+		return
+	}
+
+	cellX, cellY := hash.clampedCell(occupied, x+dx*enter, y+dy*enter)
+	lastX, lastY := hash.clampedCell(occupied, x+dx*exit, y+dy*exit)
+
+	// report calls the callback for the object if the ray hits it, returning false once the callback stops the ray.
+	report := func(object AABB) bool {
+		if _, hit := RayCast(object, x, y, dx, dy, maxFraction); !hit {
+			return true
+		}
+		value := callback(object, maxFraction)
+		switch {
+		case value == 0:
+			return false
+		case value > 0 && value < maxFraction:
+			maxFraction = value
+		}
+		return true
+	}
+
+	// The number of cells crossed is worked out with floats, as it can be too large for an int.
+	crossed := math.Abs(float64(lastX)-float64(cellX)) + math.Abs(float64(lastY)-float64(cellY)) + 1
+
+	if crossed > float64(len(hash.cells)) {
+		for key, objects := range hash.cells {
+			for _, object := range objects {
+				// Objects that span several cells are only reported from the first of them.
+				if cells := hash.entries[object]; key.X != cells.MinX || key.Y != cells.MinY {
+					continue
+				}
+				if !report(object) {
+					return
+				}
+			}
+		}
+		return
+	}
+
+	// The step from one cell to the next along each axis, the fraction at which the ray crosses into the next cell, and the
+	// fraction it takes to cross a whole cell.
+	stepX, nextX, deltaX := raySteps(x, dx, cellX, hash.CellSize)
+	stepY, nextY, deltaY := raySteps(y, dy, cellY, hash.CellSize)
+
+	// As the ray only ever moves one way along each axis, once it leaves the cells of an object (or the occupied cells),
+	// it never comes back to them, so objects are only reported from the first cell that the ray shares with them.
+	prevX, prevY, first := cellX, cellY, true
+
+	for enter <= maxFraction && occupied.contains(cellX, cellY) {
+		for _, object := range hash.cells[cellKey{cellX, cellY}] {
+			if !first && hash.entries[object].contains(prevX, prevY) {
+				continue
+			}
+			if !report(object) {
+				return
+			}
+		}
+
+		prevX, prevY, first = cellX, cellY, false
+
+		if nextX < nextY {
+			enter = nextX
+			nextX += deltaX
+			cellX += stepX
+		} else {
+			enter = nextY
+			nextY += deltaY
+			cellY += stepY
+		}
+	}
+}
+
+// clampedCell returns the cell that the point lies in, clamped to the range of cells provided.
+func (hash *SpatialHash) clampedCell(cells cellRange, x, y float64) (int, int) {
+	cellX := minInt(maxInt(hash.cellIndex(x), cells.MinX), cells.MaxX)
+	cellY := minInt(maxInt(hash.cellIndex(y), cells.MinY), cells.MaxY)
+	return cellX, cellY
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// raySteps returns the direction in which a ray moves from cell to cell along an axis, the fraction at which it first
+// crosses into another cell, and the fraction it takes to cross a whole cell.
+func raySteps(origin, delta float64, cell int, cellSize float64) (int, float64, float64) {
+	switch {
+	case delta > 0:
+		return 1, (float64(cell+1)*cellSize - origin) / delta, cellSize / delta
+	case delta < 0:
+		return -1, (float64(cell)*cellSize - origin) / delta, -cellSize / delta
+	}
+	return 0, math.Inf(1), math.Inf(1)
+}
+
+// QueryAllPairs returns every pair of objects in the SpatialHash whose AABBs overlap. Each pair is returned once, in no
+// particular order.
+func (hash *SpatialHash) QueryAllPairs() []Pair {
+	pairs := make([]Pair, 0)
+
+	for key, objects := range hash.cells {
+		for i, a := range objects {
+			cellsA := hash.entries[a]
+			for _, b := range objects[i+1:] {
+				// Pairs that share several cells are only reported from the first of them.
+				cellsB := hash.entries[b]
+				if key.X != maxInt(cellsA.MinX, cellsB.MinX) || key.Y != maxInt(cellsA.MinY, cellsB.MinY) {
+					continue
+				}
+				if Overlaps(a, b) {
+					pairs = append(pairs, Pair{a, b})
+				}
+			}
+		}
+	}
+
+	return pairs
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,194 @@
+package aabb_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv/aabb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpatialHash(t *testing.T) {
+	hash := NewSpatialHash(4)
+	objects := make([]*AABBData, 0, 500)
+	for i := 0; i < 500; i++ {
+		// Some objects span several cells, and some are outside of the positive quadrant.
+		size := 1 + rand.Float64()*6
+		object := (&AABBData{0, 0, size, size}).Move(rand.Float64()*100-50, rand.Float64()*100-50)
+		objects = append(objects, object)
+		hash.Insert(object)
+	}
+
+	matchesBruteForceAt := func(t *testing.T, query *AABBData) {
+		found := hash.QueryOverlaps(query)
+		count := 0
+		for _, object := range objects {
+			if Overlaps(object, query) {
+				count++
+				assert.Contains(t, found, object)
+			}
+		}
+		assert.Len(t, found, count)
+	}
+
+	matchesBruteForce := func(t *testing.T) {
+		matchesBruteForceAt(t, (&AABBData{0, 0, 20, 20}).Move(rand.Float64()*80-50, rand.Float64()*80-50))
+	}
+
+	t.Run("Overlaps", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			matchesBruteForce(t)
+		}
+	})
+
+	t.Run("Large queries", func(t *testing.T) {
+		// These cover far more cells than the SpatialHash holds (more than an int can count, for the first), so the cells
+		// held are looked through instead.
+		matchesBruteForceAt(t, &AABBData{-1e300, -1e300, 1e300, 1e300})
+		matchesBruteForceAt(t, &AABBData{-1e12, -10, 1e12, 10})
+		matchesBruteForceAt(t, &AABBData{-30, -30, 30, 30})
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		for frame := 0; frame < 10; frame++ {
+			for _, object := range objects {
+				*object = *object.Move(rand.Float64()*4-2, rand.Float64()*4-2)
+				hash.Update(object)
+			}
+			matchesBruteForce(t)
+		}
+	})
+
+	rayCastMatchesBruteForce := func(t *testing.T, x, y, dx, dy float64) {
+		found := []AABB{}
+		hash.RayCast(x, y, dx, dy, 1, func(object AABB, maxFraction float64) float64 {
+			found = append(found, object)
+			return maxFraction
+		})
+
+		count := 0
+		for _, object := range objects {
+			if _, hit := object.RayCast(x, y, dx, dy, 1); hit {
+				count++
+				assert.Contains(t, found, object)
+			}
+		}
+		assert.Len(t, found, count)
+	}
+
+	t.Run("RayCast", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			x, y := rand.Float64()*100-50, rand.Float64()*100-50
+			dx, dy := rand.Float64()*100-50, rand.Float64()*100-50
+			rayCastMatchesBruteForce(t, x, y, dx, dy)
+
+			// Clipping the ray finds the nearest object.
+			nearestFraction := 2.0
+			for _, object := range objects {
+				if fraction, hit := object.RayCast(x, y, dx, dy, 1); hit && fraction < nearestFraction {
+					nearestFraction = fraction
+				}
+			}
+			clipped := 2.0
+			hash.RayCast(x, y, dx, dy, 1, func(object AABB, maxFraction float64) float64 {
+				fraction, _ := object.AABB().RayCast(x, y, dx, dy, maxFraction)
+				clipped = fraction
+				return fraction
+			})
+			assert.Equal(t, nearestFraction, clipped)
+		}
+	})
+
+	t.Run("Long rays", func(t *testing.T) {
+		// Only the cells that hold objects are walked, so these don't take a billion steps.
+		rayCastMatchesBruteForce(t, 0, 0, 1e9, 0)
+		rayCastMatchesBruteForce(t, -1e9, -1e9, 2e9, 2e9)
+		rayCastMatchesBruteForce(t, 1e9, 10, -2e9, 0)
+		rayCastMatchesBruteForce(t, 0, -1e9, 0, 1e9)
+		rayCastMatchesBruteForce(t, 1e9, 1e9, 1e9, 1e9)
+
+		for _, ray := range [][5]float64{
+			{0, 0, math.Inf(1), 0, 1},
+			{0, 0, 1, math.NaN(), 1},
+			{math.Inf(-1), 0, 1, 0, 1},
+			{0, 0, 1, 0, math.Inf(1)},
+		} {
+			hit := false
+			hash.RayCast(ray[0], ray[1], ray[2], ray[3], ray[4], func(object AABB, maxFraction float64) float64 {
+				hit = true
+				return maxFraction
+			})
+			assert.False(t, hit, "rays that aren't finite don't hit anything: %v", ray)
+		}
+	})
+
+	t.Run("Pairs", func(t *testing.T) {
+		pairs := hash.QueryAllPairs()
+		seen := map[[2]AABB]bool{}
+		for _, pair := range pairs {
+			assert.False(t, seen[[2]AABB{pair.A, pair.B}] || seen[[2]AABB{pair.B, pair.A}], "pairs are returned once")
+			seen[[2]AABB{pair.A, pair.B}] = true
+		}
+
+		count := 0
+		for i, a := range objects {
+			for _, b := range objects[i+1:] {
+				if Overlaps(a, b) {
+					count++
+					assert.True(t, seen[[2]AABB{a, b}] || seen[[2]AABB{b, a}])
+				}
+			}
+		}
+		assert.Len(t, pairs, count)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		for _, object := range objects[:250] {
+			hash.Remove(object)
+		}
+		objects = objects[250:]
+		assert.Equal(t, 250, hash.Len())
+		matchesBruteForce(t)
+
+		assert.Equal(t, ErrtNotInTree, hash.TryRemove(&AABBData{0, 0, 1, 1}))
+		assert.Equal(t, ErrAlreadyInTree, hash.TryInsert(objects[0]))
+		assert.Equal(t, ErrNotAReference, hash.TryInsert(valueAABB{0, 0, 1, 1}))
+		_, err := hash.TryUpdate(&AABBData{0, 0, 1, 1})
+		assert.Equal(t, ErrtNotInTree, err)
+	})
+}
+
+func TestNewSpatialHash_InvalidCellSize(t *testing.T) {
+	for _, cellSize := range []float64{0, -4, math.NaN(), math.Inf(1)} {
+		assert.PanicsWithValue(t, ErrInvalidCellSize, func() { NewSpatialHash(cellSize) }, "%v", cellSize)
+	}
+}
+
+func TestSpatialHash_RayCastSparse(t *testing.T) {
+	// The objects are far apart, so rays between them cross many more cells than the SpatialHash holds, and the cells
+	// held are looked through instead.
+	hash := NewSpatialHash(1)
+	near := &AABBData{0, 0, 4, 4}
+	far := &AABBData{1e6, 1e6, 1e6 + 4, 1e6 + 4}
+	middle := &AABBData{5e5, 5e5, 5e5 + 4, 5e5 + 4}
+	hash.Insert(near)
+	hash.Insert(far)
+	hash.Insert(middle)
+
+	found := []AABB{}
+	hash.RayCast(-10, -10, 2e6, 2e6, 1, func(object AABB, maxFraction float64) float64 {
+		found = append(found, object)
+		return maxFraction
+	})
+	assert.ElementsMatch(t, []AABB{near, middle, far}, found)
+
+	// Clipping the ray still finds the nearest object.
+	nearest := AABB(nil)
+	hash.RayCast(2e6, 2e6, -2e6, -2e6, 1, func(object AABB, maxFraction float64) float64 {
+		fraction, _ := object.AABB().RayCast(2e6, 2e6, -2e6, -2e6, maxFraction)
+		nearest = object
+		return fraction
+	})
+	assert.Equal(t, far, nearest)
+}
@@ -0,0 +1,335 @@
+package aabb
+
+import (
+	"math"
+	"sort"
+)
+
+// SweepAndPrune keeps the objects' AABBs as a list of their start and end points along the X axis, sorted. As objects
+// move, their points are moved along the list one swap at a time, which is cheap when objects move only a little from
+// one update to the next, as they usually do. Finding all of the overlapping pairs is then a single sweep along the list.
+// It works best when objects are spread out along the X axis; many objects stacked above each other all overlap along
+// it, and have to be tested against each other.
+//
+// UpdatePairs tracks the overlapping pairs from one call to the next, reporting the pairs that began and ended
+// overlapping in between.
+type SweepAndPrune struct {
+	endpoints []sapEndpoint
+	entries   map[AABB]*sapEntry
+	pairs     map[[2]*sapEntry]bool
+	nextID    int
+	// maxWidth is the width of the widest AABB that has been in the list, which bounds how far before the start of an
+	// area queries need to start looking for objects overlapping it.
+	maxWidth float64
+}
+
+type sapEntry struct {
+	id       int
+	object   AABB
+	bounds   AABBData
+	min, max int // The indices of the entry's endpoints.
+}
+
+type sapEndpoint struct {
+	entry *sapEntry
+	isMax bool
+}
+
+func (e sapEndpoint) value() float64 {
+	if e.isMax {
+		return e.entry.bounds.MaxX
+	}
+	return e.entry.bounds.MinX
+}
+
+// before returns whether the endpoint belongs before the other one. End points go before the start points of other
+// entries with the same value, so that AABBs that only touch aren't swept as overlapping.
+func (e sapEndpoint) before(other sapEndpoint) bool {
+	v, o := e.value(), other.value()
+	if v != o || e.isMax == other.isMax {
+		return v < o
+	}
+	if e.entry == other.entry {
+		return !e.isMax
+	}
+	return e.isMax
+}
+
+// NewSweepAndPrune returns a new, empty SweepAndPrune.
+func NewSweepAndPrune() *SweepAndPrune {
+	return &SweepAndPrune{
+		entries: make(map[AABB]*sapEntry),
+		pairs:   make(map[[2]*sapEntry]bool),
+	}
+}
+
+// Len returns the number of objects in the SweepAndPrune.
+func (sap *SweepAndPrune) Len() int {
+	return len(sap.entries)
+}
+
+// Insert adds the object to the SweepAndPrune. It panics if the object is already there, or isn't a pointer; see
+// TryInsert.
+func (sap *SweepAndPrune) Insert(object AABB) {
+	if err := sap.TryInsert(object); err != nil {
+		panic(err)
+	}
+}
+
+// TryInsert adds the object to the SweepAndPrune, like Insert, but returns ErrAlreadyInTree or ErrNotAReference instead
+// of panicking.
+func (sap *SweepAndPrune) TryInsert(object AABB) error {
+	if err := checkReference(object); err != nil {
+		return err
+	}
+	if sap.entries[object] != nil {
+		return ErrAlreadyInTree
+	}
+
+	entry := &sapEntry{id: sap.nextID, object: object, bounds: *object.AABB()}
+	sap.nextID++
+	sap.entries[object] = entry
+	sap.maxWidth = math.Max(sap.maxWidth, entry.bounds.MaxX-entry.bounds.MinX)
+
+	entry.min = len(sap.endpoints)
+	entry.max = entry.min + 1
+	sap.endpoints = append(sap.endpoints, sapEndpoint{entry, false}, sapEndpoint{entry, true})
+	sap.sortEndpoint(entry.min)
+	sap.sortEndpoint(entry.max)
+	return nil
+}
+
+// Remove removes the object from the SweepAndPrune. It panics if the object isn't there; see TryRemove.
+func (sap *SweepAndPrune) Remove(object AABB) {
+	if err := sap.TryRemove(object); err != nil {
+		panic(err)
+	}
+}
+
+// TryRemove removes the object from the SweepAndPrune, like Remove, but returns ErrtNotInTree instead of panicking. The
+// pairs the object was part of are reported as ended by the next call to UpdatePairs.
+func (sap *SweepAndPrune) TryRemove(object AABB) error {
+	entry, ok := sap.entries[object]
+	if !ok {
+		return ErrtNotInTree
+	}
+
+	// Drop both endpoints, shifting those after them down.
+	kept := sap.endpoints[:entry.min]
+	for _, e := range sap.endpoints[entry.min+1:] {
+		if e.entry != entry {
+			kept = append(kept, e)
+		}
+	}
+	for i := len(kept); i < len(sap.endpoints); i++ {
+		sap.endpoints[i] = sapEndpoint{}
+	}
+	sap.endpoints = kept
+	for i := entry.min; i < len(sap.endpoints); i++ {
+		sap.setIndex(i)
+	}
+
+	delete(sap.entries, object)
+	return nil
+}
+
+// Update refreshes the position of the object in the SweepAndPrune, after it has moved. It returns whether the object's
+// endpoints changed places in the list, and panics with ErrtNotInTree if the object isn't in the SweepAndPrune; see
+// TryUpdate.
+func (sap *SweepAndPrune) Update(object AABB) bool {
+	updated, err := sap.TryUpdate(object)
+	if err != nil {
+		panic(err)
+	}
+	return updated
+}
+
+// TryUpdate refreshes the position of the object in the SweepAndPrune, like Update, but returns ErrtNotInTree instead of
+// panicking.
+func (sap *SweepAndPrune) TryUpdate(object AABB) (bool, error) {
+	entry, ok := sap.entries[object]
+	if !ok {
+		return false, ErrtNotInTree
+	}
+
+	previousMinX := entry.bounds.MinX
+	entry.bounds = *object.AABB()
+	sap.maxWidth = math.Max(sap.maxWidth, entry.bounds.MaxX-entry.bounds.MinX)
+
+	min, max := entry.min, entry.max
+	// An endpoint moving towards the other one could get stuck behind it, so the other one is moved out of the way first.
+	if entry.bounds.MinX > previousMinX {
+		sap.sortEndpoint(entry.max)
+		sap.sortEndpoint(entry.min)
+	} else {
+		sap.sortEndpoint(entry.min)
+		sap.sortEndpoint(entry.max)
+	}
+	return entry.min != min || entry.max != max, nil
+}
+
+// sortEndpoint moves the endpoint at index i left or right until it's in order, by insertion sort.
+func (sap *SweepAndPrune) sortEndpoint(i int) {
+	for i > 0 && sap.endpoints[i].before(sap.endpoints[i-1]) {
+		sap.swap(i, i-1)
+		i--
+	}
+	for i < len(sap.endpoints)-1 && sap.endpoints[i+1].before(sap.endpoints[i]) {
+		sap.swap(i, i+1)
+		i++
+	}
+}
+
+func (sap *SweepAndPrune) swap(i, j int) {
+	sap.endpoints[i], sap.endpoints[j] = sap.endpoints[j], sap.endpoints[i]
+	sap.setIndex(i)
+	sap.setIndex(j)
+}
+
+// setIndex stores the index i in the entry of the endpoint at that index.
+func (sap *SweepAndPrune) setIndex(i int) {
+	e := sap.endpoints[i]
+	if e.isMax {
+		e.entry.max = i
+	} else {
+		e.entry.min = i
+	}
+}
+
+// sweep calls found for each pair of entries whose AABBs overlap, as found by sweeping along the sorted endpoints.
+func (sap *SweepAndPrune) sweep(found func(a, b *sapEntry)) {
+	active := make([]*sapEntry, 0)
+	for _, e := range sap.endpoints {
+		if e.isMax {
+			for i, entry := range active {
+				if entry == e.entry {
+					active[i] = active[len(active)-1]
+					active = active[:len(active)-1]
+					break
+				}
+			}
+			continue
+		}
+		for _, other := range active {
+			if Overlaps(&e.entry.bounds, &other.bounds) {
+				found(other, e.entry)
+			}
+		}
+		active = append(active, e.entry)
+	}
+}
+
+// QueryAllPairs returns every pair of objects in the SweepAndPrune whose AABBs overlap, as of their last update. Each
+// pair is returned once, in no particular order.
+func (sap *SweepAndPrune) QueryAllPairs() []Pair {
+	pairs := make([]Pair, 0)
+	sap.sweep(func(a, b *sapEntry) {
+		pairs = append(pairs, Pair{a.object, b.object})
+	})
+	return pairs
+}
+
+// UpdatePairs finds the pairs of objects whose AABBs overlap, as of their last update, and returns the pairs that began
+// overlapping, and the ones that stopped overlapping, since the last time it was called. Pairs including objects that
+// were removed in between are reported as ended. The objects of each pair are always given in the same order.
+func (sap *SweepAndPrune) UpdatePairs() (began, ended []Pair) {
+	began = make([]Pair, 0)
+	ended = make([]Pair, 0)
+
+	current := make(map[[2]*sapEntry]bool, len(sap.pairs))
+	sap.sweep(func(a, b *sapEntry) {
+		if b.id < a.id {
+			a, b = b, a
+		}
+		key := [2]*sapEntry{a, b}
+		current[key] = true
+		if !sap.pairs[key] {
+			began = append(began, Pair{a.object, b.object})
+		}
+	})
+
+	for key := range sap.pairs {
+		if !current[key] {
+			ended = append(ended, Pair{key[0].object, key[1].object})
+		}
+	}
+
+	sap.pairs = current
+	return began, ended
+}
+
+// candidates calls visit for each entry whose AABB, along the X axis, overlaps the span from minX to maxX, until visit
+// returns false.
+func (sap *SweepAndPrune) candidates(minX, maxX float64, visit func(entry *sapEntry) bool) {
+	// No entry that starts further back than the widest one could reach minX.
+	start := sort.Search(len(sap.endpoints), func(i int) bool {
+		return sap.endpoints[i].value() >= minX-sap.maxWidth
+	})
+
+	for _, e := range sap.endpoints[start:] {
+		if e.value() > maxX {
+			return
+		}
+		if !e.isMax && e.entry.bounds.MaxX >= minX && !visit(e.entry) {
+			return
+		}
+	}
+}
+
+// QueryOverlaps returns the objects in the SweepAndPrune whose AABBs overlap the object's AABB, not counting the object
+// itself.
+func (sap *SweepAndPrune) QueryOverlaps(object AABB) []AABB {
+	return sap.QueryOverlapsInto(make([]AABB, 0), object)
+}
+
+// QueryOverlapsInto appends the objects in the SweepAndPrune whose AABBs overlap the object's AABB, not counting the
+// object itself, to dst, and returns the extended slice.
+func (sap *SweepAndPrune) QueryOverlapsInto(dst []AABB, object AABB) []AABB {
+	sap.QueryOverlapsFunc(object, func(found AABB) bool {
+		dst = append(dst, found)
+		return true
+	})
+	return dst
+}
+
+// QueryOverlapsFunc calls visit for each object in the SweepAndPrune whose AABB overlaps the object's AABB, not counting
+// the object itself, until visit returns false. The objects' current AABBs are tested, but they're only found if they
+// were near the area queried when they were last updated.
+func (sap *SweepAndPrune) QueryOverlapsFunc(object AABB, visit func(found AABB) bool) {
+	testAABB := object.AABB()
+	sap.candidates(testAABB.MinX, testAABB.MaxX, func(entry *sapEntry) bool {
+		if entry.object == object || !Overlaps(entry.object, testAABB) {
+			return true
+		}
+		return visit(entry.object)
+	})
+}
+
+// RayCast casts a ray starting at x, y and moving along dx, dy, calling the callback for each object whose AABB the ray
+// hits between the fractions 0 and maxFraction of dx, dy; see Tree.RayCast. Every object within the span the ray covers
+// along the X axis is tested, so rays that mostly move along the Y axis are the cheapest.
+func (sap *SweepAndPrune) RayCast(x, y, dx, dy, maxFraction float64, callback RayCastCallback) {
+	if maxFraction <= 0 {
+		return
+	}
+
+	minX, maxX := x, x+dx*maxFraction
+	if maxX < minX {
+		minX, maxX = maxX, minX
+	}
+
+	sap.candidates(minX, maxX, func(entry *sapEntry) bool {
+		if _, hit := RayCast(entry.object, x, y, dx, dy, maxFraction); !hit {
+			return true
+		}
+
+		value := callback(entry.object, maxFraction)
+		switch {
+		case value == 0:
+			return false
+		case value > 0 && value < maxFraction:
+			maxFraction = value
+		}
+		return true
+	})
+}
@@ -0,0 +1,231 @@
+package aabb_test
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv/aabb"
+	"github.com/stretchr/testify/assert"
+)
+
+// pairSet returns the pairs provided as a set, in a way that doesn't depend on the order of the objects in each pair.
+func pairSet(pairs []Pair) map[[2]AABB]bool {
+	set := map[[2]AABB]bool{}
+	for _, pair := range pairs {
+		set[[2]AABB{pair.A, pair.B}] = true
+		set[[2]AABB{pair.B, pair.A}] = true
+	}
+	return set
+}
+
+func bruteForcePairs(objects []*AABBData) []Pair {
+	pairs := []Pair{}
+	for i, a := range objects {
+		for _, b := range objects[i+1:] {
+			if Overlaps(a, b) {
+				pairs = append(pairs, Pair{a, b})
+			}
+		}
+	}
+	return pairs
+}
+
+func TestSweepAndPrune(t *testing.T) {
+	sap := NewSweepAndPrune()
+	objects := make([]*AABBData, 0, 300)
+	for i := 0; i < 300; i++ {
+		size := 1 + rand.Float64()*4
+		object := (&AABBData{0, 0, size, size}).Move(rand.Float64()*100, rand.Float64()*100)
+		objects = append(objects, object)
+		sap.Insert(object)
+	}
+
+	matchesBruteForce := func(t *testing.T) {
+		query := (&AABBData{0, 0, 20, 20}).Move(rand.Float64()*80, rand.Float64()*80)
+		found := sap.QueryOverlaps(query)
+		count := 0
+		for _, object := range objects {
+			if Overlaps(object, query) {
+				count++
+				assert.Contains(t, found, object)
+			}
+		}
+		assert.Len(t, found, count)
+
+		expected := bruteForcePairs(objects)
+		pairs := sap.QueryAllPairs()
+		assert.Len(t, pairs, len(expected))
+		assert.Equal(t, pairSet(expected), pairSet(pairs))
+	}
+
+	t.Run("Overlaps", func(t *testing.T) {
+		matchesBruteForce(t)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		previous := pairSet(nil)
+		began, _ := sap.UpdatePairs()
+		for _, pair := range began {
+			previous[[2]AABB{pair.A, pair.B}] = true
+			previous[[2]AABB{pair.B, pair.A}] = true
+		}
+
+		for frame := 0; frame < 20; frame++ {
+			for i, object := range objects {
+				if i%50 == 0 {
+					// Now and then, an object jumps far away.
+					*object = *object.Move(rand.Float64()*100-50, rand.Float64()*100-50)
+				} else {
+					*object = *object.Move(rand.Float64()*4-2, rand.Float64()*4-2)
+				}
+				sap.Update(object)
+			}
+			matchesBruteForce(t)
+
+			current := pairSet(bruteForcePairs(objects))
+			began, ended := sap.UpdatePairs()
+			for _, pair := range began {
+				assert.True(t, current[[2]AABB{pair.A, pair.B}])
+				assert.False(t, previous[[2]AABB{pair.A, pair.B}])
+			}
+			for _, pair := range ended {
+				assert.False(t, current[[2]AABB{pair.A, pair.B}])
+				assert.True(t, previous[[2]AABB{pair.A, pair.B}])
+			}
+			// Every change is reported.
+			changes := 0
+			for pair := range current {
+				if !previous[pair] {
+					changes++
+				}
+			}
+			for pair := range previous {
+				if !current[pair] {
+					changes++
+				}
+			}
+			assert.Equal(t, changes, 2*(len(began)+len(ended)))
+			previous = current
+		}
+	})
+
+	t.Run("RayCast", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			x, y := rand.Float64()*100, rand.Float64()*100
+			dx, dy := rand.Float64()*100-50, rand.Float64()*100-50
+
+			found := []AABB{}
+			sap.RayCast(x, y, dx, dy, 1, func(object AABB, maxFraction float64) float64 {
+				found = append(found, object)
+				return maxFraction
+			})
+
+			count := 0
+			for _, object := range objects {
+				if _, hit := object.RayCast(x, y, dx, dy, 1); hit {
+					count++
+					assert.Contains(t, found, object)
+				}
+			}
+			assert.Len(t, found, count)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		removed := objects[:100]
+		for _, object := range removed {
+			sap.Remove(object)
+		}
+		objects = objects[100:]
+		assert.Equal(t, 200, sap.Len())
+		matchesBruteForce(t)
+
+		// Pairs including removed objects end.
+		_, ended := sap.UpdatePairs()
+		for _, pair := range ended {
+			assert.True(t, containsObject(removed, pair.A) || containsObject(removed, pair.B))
+		}
+
+		assert.Equal(t, ErrtNotInTree, sap.TryRemove(removed[0]))
+		assert.Equal(t, ErrAlreadyInTree, sap.TryInsert(objects[0]))
+		assert.Equal(t, ErrNotAReference, sap.TryInsert(valueAABB{0, 0, 1, 1}))
+		_, err := sap.TryUpdate(removed[0])
+		assert.Equal(t, ErrtNotInTree, err)
+	})
+
+	t.Run("Touching", func(t *testing.T) {
+		sap := NewSweepAndPrune()
+		a := &AABBData{0, 0, 1, 1}
+		b := &AABBData{1, 0, 2, 1}
+		point := &AABBData{0.5, 0.5, 0.5, 0.5}
+		sap.Insert(a)
+		sap.Insert(b)
+		sap.Insert(point)
+
+		assert.Equal(t, []Pair{{a, point}}, sap.QueryAllPairs())
+	})
+}
+
+func containsObject(objects []*AABBData, object AABB) bool {
+	for _, o := range objects {
+		if o == object {
+			return true
+		}
+	}
+	return false
+}
+
+// BenchmarkBroadphasePairs moves objects along the X axis, like the bullets and enemies of a shoot 'em up, finding the
+// overlapping pairs every frame.
+func BenchmarkBroadphasePairs(b *testing.B) {
+	newObjects := func() []*AABBData {
+		objects := make([]*AABBData, 0, 2000)
+		for i := 0; i < 2000; i++ {
+			objects = append(objects, (&AABBData{0, 0, 4, 4}).Move(rand.Float64()*1000, rand.Float64()*600))
+		}
+		return objects
+	}
+
+	move := func(objects []*AABBData, frame int) {
+		for i, object := range objects {
+			speed := float64(i%7 - 3)
+			if frame%100 >= 50 {
+				speed = -speed
+			}
+			*object = *object.Move(speed, 0)
+		}
+	}
+
+	b.Run("Tree", func(b *testing.B) {
+		objects := newObjects()
+		tree := NewTree()
+		tree.Margin = 2
+		for _, object := range objects {
+			tree.Insert(object)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			move(objects, i)
+			for _, object := range objects {
+				tree.Update(object)
+			}
+			tree.QueryAllPairs()
+		}
+	})
+
+	b.Run("SweepAndPrune", func(b *testing.B) {
+		objects := newObjects()
+		sap := NewSweepAndPrune()
+		for _, object := range objects {
+			sap.Insert(object)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			move(objects, i)
+			for _, object := range objects {
+				sap.Update(object)
+			}
+			sap.UpdatePairs()
+		}
+	})
+}
@@ -1,12 +1,23 @@
 package aabb
 
 import (
+	"math"
 	"reflect"
+	"sort"
+	"sync"
 )
 
+// Tree is a dynamic AABB tree. Leaves store "fat" AABBs, which are the objects' AABBs grown by Margin, so that objects
+// that move only a little don't need to be reinserted into the tree when they're updated (see Update).
 type Tree struct {
 	Root         *treeNode
 	NodeIndexMap map[AABB]*treeNode
+
+	// Margin is how much the AABB of each leaf is grown by on every side.
+	Margin float64
+	// DisplacementMultiplier is how far, as a multiple of the displacement an object moved by since it was last updated,
+	// the AABB of its leaf is extended in the direction it's moving when it's reinserted, predicting its next movement.
+	DisplacementMultiplier float64
 }
 
 func NewTree() *Tree {
@@ -15,12 +26,43 @@ func NewTree() *Tree {
 	}
 }
 
+// NewTreeFromObjects builds a tree holding all of the objects provided in one pass, top-down, which is both faster and
+// results in a better tree than inserting them one by one. The leaves get no margin; one can be given to them by setting
+// the tree's Margin and updating the objects as they move. It returns ErrNotAReference if any of the objects isn't a
+// pointer, or ErrAlreadyInTree if an object is listed more than once.
+func NewTreeFromObjects(objects []AABB) (*Tree, error) {
+	tree := NewTree()
+	leaves := make([]*treeNode, 0, len(objects))
+
+	for _, object := range objects {
+		if err := checkReference(object); err != nil {
+			return nil, err
+		}
+		if tree.NodeIndexMap[object] != nil {
+			return nil, ErrAlreadyInTree
+		}
+
+		node := newTreeNode(object)
+		node.Tight = *object.AABB()
+		node.ObjectAABB = tree.fatten(&node.Tight, 0, 0)
+		tree.NodeIndexMap[object] = node
+		leaves = append(leaves, node)
+	}
+
+	tree.Root = buildTopDown(leaves)
+	return tree, nil
+}
+
 func (tree *Tree) IsEmpty() bool {
 	return tree.Root == nil
 }
 
 func (tree *Tree) Depth() int {
+	if tree.IsEmpty() {
+		return 0
+	}
 	stack := newTreeNodeStack()
+	tree.Root.Depth = 0
 	stack.Push(tree.Root)
 	var maxDepth int
 	for !stack.Empty() {
@@ -41,24 +83,106 @@ func (tree *Tree) Depth() int {
 	return maxDepth
 }
 
+// Insert adds the object to the tree. It panics if the object is already in the tree, or isn't a pointer; see TryInsert.
 func (tree *Tree) Insert(object AABB) {
+	if err := tree.TryInsert(object); err != nil {
+		panic(err)
+	}
+}
+
+// TryInsert adds the object to the tree, like Insert, but returns ErrAlreadyInTree or ErrNotAReference instead of
+// panicking.
+func (tree *Tree) TryInsert(object AABB) error {
+	if err := checkReference(object); err != nil {
+		return err
+	}
 	if tree.NodeIndexMap[object] != nil {
-		panic(ErrAlreadyInTree)
+		return ErrAlreadyInTree
 	}
 
 	node := newTreeNode(object)
+	node.Tight = *object.AABB()
+	node.ObjectAABB = tree.fatten(&node.Tight, 0, 0)
 	tree.insertLeaf(node)
 	tree.NodeIndexMap[object] = node
+	return nil
+}
+
+// Update refreshes the position of the object in the tree, after it has moved. As long as the object's AABB is still
+// within the fat AABB of its leaf, nothing needs to be done; otherwise, the leaf is reinserted with a new fat AABB.
+// It returns whether the leaf was reinserted, and panics with ErrtNotInTree if the object isn't in the tree; see TryUpdate.
+func (tree *Tree) Update(object AABB) bool {
+	updated, err := tree.TryUpdate(object)
+	if err != nil {
+		panic(err)
+	}
+	return updated
+}
+
+// TryUpdate refreshes the position of the object in the tree, like Update, but returns ErrtNotInTree instead of
+// panicking.
+func (tree *Tree) TryUpdate(object AABB) (bool, error) {
+	node, ok := tree.NodeIndexMap[object]
+	if !ok {
+		return false, ErrtNotInTree
+	}
+
+	current := *object.AABB()
+	previous := node.Tight
+	node.Tight = current
+
+	if Contains(node.ObjectAABB, &current) {
+		return false, nil
+	}
+
+	dx := (current.MinX + current.MaxX - previous.MinX - previous.MaxX) / 2
+	dy := (current.MinY + current.MaxY - previous.MinY - previous.MaxY) / 2
+
+	tree.removeLeaf(node)
+	node.ObjectAABB = tree.fatten(&current, dx, dy)
+	tree.insertLeaf(node)
+	return true, nil
 }
 
+// fatten returns the fat AABB for a leaf; the AABB grown by the tree's margin, and extended along the displacement by
+// the displacement multiplier.
+func (tree *Tree) fatten(aabb *AABBData, dx, dy float64) *AABBData {
+	fat := Expand(aabb, tree.Margin)
+
+	dx *= tree.DisplacementMultiplier
+	dy *= tree.DisplacementMultiplier
+
+	if dx < 0 {
+		fat.MinX += dx
+	} else {
+		fat.MaxX += dx
+	}
+
+	if dy < 0 {
+		fat.MinY += dy
+	} else {
+		fat.MaxY += dy
+	}
+
+	return fat
+}
+
+// Remove removes the object from the tree. It panics if the object isn't in the tree; see TryRemove.
 func (tree *Tree) Remove(object AABB) {
+	if err := tree.TryRemove(object); err != nil {
+		panic(err)
+	}
+}
 
+// TryRemove removes the object from the tree, like Remove, but returns ErrtNotInTree instead of panicking.
+func (tree *Tree) TryRemove(object AABB) error {
 	node, ok := tree.NodeIndexMap[object]
 	if !ok {
-		panic(ErrtNotInTree)
+		return ErrtNotInTree
 	}
 	tree.removeLeaf(node)
 	delete(tree.NodeIndexMap, object)
+	return nil
 }
 func (tree *Tree) removeLeaf(node *treeNode) {
 	if node == tree.Root {
@@ -145,60 +269,345 @@ func (tree *Tree) insertLeaf(node *treeNode) {
 	tree.fixUpwardsTree(node.Parent)
 }
 
+// fixUpwardsTree refits the AABBs and heights of the node and all of its ancestors, rotating them as it goes to keep the
+// tree balanced.
 func (tree *Tree) fixUpwardsTree(node *treeNode) {
 	for node != nil {
-		node.ObjectAABB = Merge(node.Left, node.Right)
+		node = tree.balance(node)
+		node.refit()
 		node = node.Parent
 	}
 }
 
-func (tree *Tree) QueryOverlaps(object AABB) []AABB {
-	if reflect.ValueOf(object).Kind() != reflect.Ptr {
-		panic(ErrNotAReference)
+// balance performs a left or right rotation if the subtree rooted at the node is imbalanced (if the heights of its
+// children differ by more than one), returning the node that takes its place in the tree.
+func (tree *Tree) balance(a *treeNode) *treeNode {
+	if a.IsLeaf() || a.Height < 2 {
+		return a
+	}
+
+	b := a.Left
+	c := a.Right
+	balance := c.Height - b.Height
+
+	switch {
+	case balance > 1:
+		return tree.rotateUp(a, c, b)
+	case balance < -1:
+		return tree.rotateUp(a, b, c)
+	}
+
+	return a
+}
+
+// rotateUp promotes the child of the node a that is too tall, putting a in its place and handing a the shorter of the
+// child's own children.
+func (tree *Tree) rotateUp(a, tall, short *treeNode) *treeNode {
+	f := tall.Left
+	g := tall.Right
+
+	// The tall child takes a's place.
+	tall.Parent = a.Parent
+	switch {
+	case a.Parent == nil:
+		tree.Root = tall
+	case a.Parent.Left == a:
+		a.Parent.Left = tall
+	default:
+		a.Parent.Right = tall
+	}
+
+	// The taller of the tall child's children stays with it, while the other moves under a.
+	keep, give := f, g
+	if g.Height > f.Height {
+		keep, give = g, f
+	}
+
+	tall.Left = a
+	tall.Right = keep
+	a.Parent = tall
+
+	a.Left = short
+	a.Right = give
+	give.Parent = a
+
+	a.refit()
+	tall.refit()
+
+	return tall
+}
+
+// Rebuild rebuilds the tree from scratch, top-down, which generally results in a better tree than the one built up by
+// inserting, removing, and updating leaves one at a time. Leaves keep their current fat AABBs.
+func (tree *Tree) Rebuild() {
+	leaves := make([]*treeNode, 0, len(tree.NodeIndexMap))
+	for _, node := range tree.NodeIndexMap {
+		leaves = append(leaves, node)
+	}
+	tree.Root = buildTopDown(leaves)
+	if tree.Root != nil {
+		tree.Root.Parent = nil
+	}
+}
+
+// buildTopDown builds a subtree out of the leaves provided, splitting them in half by the median of their centers along
+// the longest axis of the area the centers cover, and returns the root of the subtree.
+func buildTopDown(leaves []*treeNode) *treeNode {
+	switch len(leaves) {
+	case 0:
+		return nil
+	case 1:
+		leaves[0].Left = nil
+		leaves[0].Right = nil
+		leaves[0].Height = 0
+		return leaves[0]
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, leaf := range leaves {
+		x, y := leaf.ObjectAABB.center()
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
 	}
-	overlaps := make([]AABB, 0)
 
+	byX := maxX-minX >= maxY-minY
+	sort.Slice(leaves, func(i, j int) bool {
+		xi, yi := leaves[i].ObjectAABB.center()
+		xj, yj := leaves[j].ObjectAABB.center()
+		if byX {
+			return xi < xj
+		}
+		return yi < yj
+	})
+
+	half := len(leaves) / 2
+	node := &treeNode{
+		Left:  buildTopDown(leaves[:half]),
+		Right: buildTopDown(leaves[half:]),
+	}
+	node.Left.Parent = node
+	node.Right.Parent = node
+	node.refit()
+	return node
+}
+
+// AreaCost returns the sum of the surface areas of all of the tree's internal nodes. This is what the surface area
+// heuristic used for insertion tries to minimize, so it can be used to compare the quality of different trees built
+// out of the same objects; the lower, the better.
+func (tree *Tree) AreaCost() float64 {
 	if tree.IsEmpty() {
-		return overlaps
+		return 0
 	}
 
+	cost := 0.0
 	stack := newTreeNodeStack()
+	stack.Push(tree.Root)
+	for !stack.Empty() {
+		node := stack.Pop()
+		if node.IsLeaf() {
+			continue
+		}
+		cost += node.AABB().SurfaceArea()
+		stack.Push(node.Left)
+		stack.Push(node.Right)
+	}
+	return cost
+}
+
+// Height returns the height of the tree; the number of levels below the root. An empty tree, or one with a single leaf,
+// has a height of 0.
+func (tree *Tree) Height() int {
+	if tree.IsEmpty() {
+		return 0
+	}
+	return tree.Root.Height
+}
+
+// QueryOverlaps returns the objects in the tree whose AABBs overlap the object's AABB, not counting the object itself. It
+// panics with ErrNotAReference if the object isn't a pointer.
+func (tree *Tree) QueryOverlaps(object AABB) []AABB {
+	if err := checkReference(object); err != nil {
+		panic(err)
+	}
+	return tree.QueryOverlapsInto(make([]AABB, 0), object)
+}
+
+// QueryOverlapsInto appends the objects in the tree whose AABBs overlap the object's AABB, not counting the object
+// itself, to dst, and returns the extended slice. Passing the result of a previous query, resliced to dst[:0], reuses its
+// memory, so that querying doesn't allocate once the slice is large enough.
+func (tree *Tree) QueryOverlapsInto(dst []AABB, object AABB) []AABB {
+	tree.QueryOverlapsFunc(object, func(found AABB) bool {
+		dst = append(dst, found)
+		return true
+	})
+	return dst
+}
+
+// QueryOverlapsFunc calls visit for each object in the tree whose AABB overlaps the object's AABB, not counting the
+// object itself, until visit returns false.
+func (tree *Tree) QueryOverlapsFunc(object AABB, visit func(found AABB) bool) {
+	if tree.IsEmpty() {
+		return
+	}
+
+	stack := acquireStack()
+	defer releaseStack(stack)
+
 	testAABB := object.AABB()
 	stack.Push(tree.Root)
 
 	for !stack.Empty() {
 		node := stack.Pop()
 
-		if Overlaps(node, testAABB) {
-			if node.IsLeaf() && node.Object != object {
-				overlaps = append(overlaps, node.Object)
-				continue
-			}
-			if node.Left != nil {
-				stack.Push(node.Left)
-			}
-			if node.Right != nil {
-				stack.Push(node.Right)
+		if !Overlaps(node, testAABB) {
+			continue
+		}
+
+		if node.IsLeaf() {
+			// The fat AABB of the leaf overlapping doesn't mean the object itself does.
+			if node.Object != object && Overlaps(node.Object, testAABB) && !visit(node.Object) {
+				return
 			}
+			continue
+		}
+
+		stack.Push(node.Left)
+		stack.Push(node.Right)
+	}
+}
+
+// Pair is a pair of objects whose AABBs overlap.
+type Pair struct {
+	A, B AABB
+}
+
+// QueryAllPairs returns every pair of objects in the tree whose AABBs overlap. Each pair is returned once, in no
+// particular order; the tree is descended on both sides of each pair at the same time, so that subtrees that don't
+// overlap are never compared.
+func (tree *Tree) QueryAllPairs() []Pair {
+	pairs := make([]Pair, 0)
+	if !tree.IsEmpty() {
+		selfPairs(tree.Root, &pairs)
+	}
+	return pairs
+}
+
+// QueryTreePairs returns every pair of objects, one from this tree (A) and one from the other tree (B), whose AABBs
+// overlap. An object that's in both trees isn't paired with itself.
+func (tree *Tree) QueryTreePairs(other *Tree) []Pair {
+	pairs := make([]Pair, 0)
+	if !tree.IsEmpty() && !other.IsEmpty() {
+		crossPairs(tree.Root, other.Root, &pairs)
+	}
+	return pairs
+}
+
+// selfPairs finds the overlapping pairs of objects within the subtree rooted at the node.
+func selfPairs(node *treeNode, pairs *[]Pair) {
+	if node.IsLeaf() {
+		return
+	}
+	selfPairs(node.Left, pairs)
+	selfPairs(node.Right, pairs)
+	crossPairs(node.Left, node.Right, pairs)
+}
+
+// crossPairs finds the overlapping pairs made of an object in the subtree rooted at a and one in the subtree rooted at b.
+func crossPairs(a, b *treeNode, pairs *[]Pair) {
+	if !Overlaps(a, b) {
+		return
+	}
+
+	switch {
+	case a.IsLeaf() && b.IsLeaf():
+		if a.Object != b.Object && Overlaps(a.Object, b.Object) {
+			*pairs = append(*pairs, Pair{a.Object, b.Object})
+		}
+	case b.IsLeaf() || (!a.IsLeaf() && a.AABB().SurfaceArea() > b.AABB().SurfaceArea()):
+		// Descending into the larger node first keeps both sides of the pair at about the same size.
+		crossPairs(a.Left, b, pairs)
+		crossPairs(a.Right, b, pairs)
+	default:
+		crossPairs(a, b.Left, pairs)
+		crossPairs(a, b.Right, pairs)
+	}
+}
+
+// RayCastCallback is called by Tree.RayCast for each object whose AABB is hit by the ray, with the current maxFraction of
+// the ray. It returns the new maxFraction: returning the fraction at which the ray hits the object clips the ray, so that
+// only objects closer than it are reported from then on, returning maxFraction (or anything greater) leaves the ray as it
+// is, returning a negative value ignores the object, and returning 0 stops the ray cast altogether.
+type RayCastCallback func(object AABB, maxFraction float64) float64
+
+// RayCast casts a ray starting at x, y and moving along dx, dy through the tree, calling the callback for each object
+// whose AABB the ray hits between the fractions 0 and maxFraction of dx, dy. Casting with a maxFraction of 1 tests the
+// segment from x, y to x+dx, y+dy. Objects aren't reported in any particular order; to find the nearest one, the callback
+// should clip the ray to the objects it hits.
+func (tree *Tree) RayCast(x, y, dx, dy, maxFraction float64, callback RayCastCallback) {
+	if tree.IsEmpty() || maxFraction <= 0 {
+		return
+	}
+
+	stack := acquireStack()
+	defer releaseStack(stack)
+	stack.Push(tree.Root)
+
+	for !stack.Empty() {
+		node := stack.Pop()
+
+		if _, hit := RayCast(node, x, y, dx, dy, maxFraction); !hit {
+			continue
+		}
+
+		if !node.IsLeaf() {
+			stack.Push(node.Left)
+			stack.Push(node.Right)
+			continue
+		}
+
+		// As with QueryOverlaps, the object itself has to be hit, not only the fat AABB of its leaf.
+		if _, hit := RayCast(node.Object, x, y, dx, dy, maxFraction); !hit {
+			continue
+		}
+
+		value := callback(node.Object, maxFraction)
+		switch {
+		case value == 0:
+			return
+		case value > 0 && value < maxFraction:
+			maxFraction = value
 		}
 	}
-	return overlaps
 }
 
 type treeNode struct {
 	Object     AABB      `json:"-"`
 	ObjectAABB *AABBData `json:"aabb"`
+	// Tight is the AABB the object had when the leaf was last inserted or updated.
+	Tight AABBData `json:"-"`
 
 	Parent *treeNode `json:"-"`
 	Left   *treeNode `json:"left"`
 	Right  *treeNode `json:"right"`
 
 	Depth int `json:"depth"`
+	// Height is the number of levels below the node; leaves have a height of 0.
+	Height int `json:"height"`
 }
 
-func newTreeNode(object AABB) *treeNode {
+// checkReference returns ErrNotAReference if the object isn't a pointer. Objects are told apart by identity, so copies of
+// an object would never be found in the tree.
+func checkReference(object AABB) error {
 	if reflect.ValueOf(object).Kind() != reflect.Ptr {
-		panic(ErrNotAReference)
+		return ErrNotAReference
+	}
+	return nil
+}
+
+func newTreeNode(object AABB) *treeNode {
+	if err := checkReference(object); err != nil {
+		panic(err)
 	}
 	return &treeNode{
 		Object:     object,
@@ -206,6 +615,15 @@ func newTreeNode(object AABB) *treeNode {
 	}
 }
 
+// refit recalculates the AABB and height of an internal node from its children.
+func (node *treeNode) refit() {
+	node.ObjectAABB = Merge(node.Left, node.Right)
+	node.Height = 1 + node.Left.Height
+	if node.Right.Height >= node.Left.Height {
+		node.Height = 1 + node.Right.Height
+	}
+}
+
 func (node *treeNode) IsLeaf() bool {
 	return node.Left == nil
 }
@@ -266,3 +684,23 @@ func (stack *treeNodeStack) Pop() *treeNode {
 func (stack *treeNodeStack) Empty() bool {
 	return len(stack.data) == 0
 }
+
+// stackPool holds the traversal stacks that aren't in use, so that queries don't need to allocate new ones. It's shared by
+// all trees and safe for concurrent use, so queries don't modify the tree they run on, and can run alongside each other;
+// queries that are run from within the callback of another query take a stack of their own.
+var stackPool = sync.Pool{
+	New: func() interface{} {
+		return newTreeNodeStack()
+	},
+}
+
+// acquireStack returns an empty stack to traverse a tree with, reusing one that was released if there is any.
+func acquireStack() *treeNodeStack {
+	return stackPool.Get().(*treeNodeStack)
+}
+
+// releaseStack returns a stack to the pool once it's no longer in use, so that it can be reused.
+func releaseStack(stack *treeNodeStack) {
+	stack.data = stack.data[:0]
+	stackPool.Put(stack)
+}
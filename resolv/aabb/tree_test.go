@@ -3,6 +3,7 @@ package aabb_test
 import (
 	"math"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
@@ -194,3 +195,468 @@ func TestAABBTree_Remove(t *testing.T) {
 		})
 	})
 }
+
+func TestAABBTree_Update(t *testing.T) {
+	t.Run("Moving within the fat AABB", func(t *testing.T) {
+		tree := NewTree()
+		tree.Margin = 1
+		a := &AABBData{0, 0, 1, 1}
+		b := &AABBData{5, 5, 6, 6}
+		tree.Insert(a)
+		tree.Insert(b)
+
+		*a = *a.Move(0.5, 0.5)
+		assert.False(t, tree.Update(a))
+
+		// The leaf wasn't reinserted, but queries still use the object's current position.
+		assert.Contains(t, tree.QueryOverlaps(&AABBData{1.2, 1.2, 1.4, 1.4}), a)
+		assert.Empty(t, tree.QueryOverlaps(&AABBData{-0.8, -0.8, -0.2, -0.2}))
+	})
+
+	t.Run("Leaving the fat AABB", func(t *testing.T) {
+		tree := NewTree()
+		tree.Margin = 1
+		tree.DisplacementMultiplier = 2
+		a := &AABBData{0, 0, 1, 1}
+		b := &AABBData{5, 5, 6, 6}
+		tree.Insert(a)
+		tree.Insert(b)
+
+		*a = *a.Move(3, 0)
+		assert.True(t, tree.Update(a))
+		assert.Contains(t, tree.QueryOverlaps(&AABBData{3.5, 0.5, 3.6, 0.6}), a)
+		assert.Empty(t, tree.QueryOverlaps(&AABBData{0.2, 0.2, 0.4, 0.4}))
+
+		// The fat AABB was extended by twice the displacement in the direction of movement, so moving on by the same
+		// amount doesn't need a reinsertion.
+		*a = *a.Move(3, 0)
+		assert.False(t, tree.Update(a))
+		*a = *a.Move(-7, 0)
+		assert.True(t, tree.Update(a))
+	})
+
+	t.Run("Matches brute force", func(t *testing.T) {
+		tree := NewTree()
+		tree.Margin = 0.5
+		tree.DisplacementMultiplier = 4
+		objects := make([]*AABBData, 0, 500)
+
+		for i := 0; i < 500; i++ {
+			object := (&AABBData{0, 0, 1, 1}).Move(rand.Float64()*100, rand.Float64()*100)
+			objects = append(objects, object)
+			tree.Insert(object)
+		}
+
+		for frame := 0; frame < 10; frame++ {
+			for _, object := range objects {
+				*object = *object.Move(rand.Float64()*4-2, rand.Float64()*4-2)
+				tree.Update(object)
+			}
+
+			query := (&AABBData{0, 0, 10, 10}).Move(rand.Float64()*90, rand.Float64()*90)
+			found := tree.QueryOverlaps(query)
+			count := 0
+			for _, object := range objects {
+				if Overlaps(object, query) {
+					count++
+					assert.Contains(t, found, object)
+				}
+			}
+			assert.Len(t, found, count)
+		}
+	})
+
+	t.Run("Update non existing object", func(t *testing.T) {
+		tree := NewTree()
+		assert.PanicsWithValue(t, ErrtNotInTree, func() {
+			tree.Update(&AABBData{0, 0, 1, 1})
+		})
+	})
+}
+
+func TestAABBTree_Balance(t *testing.T) {
+	t.Run("Sequential inserts", func(t *testing.T) {
+		tree := NewTree()
+		count := 1024
+		for i := 0; i < count; i++ {
+			tree.Insert(&AABBData{float64(i), 0, float64(i) + 1, 1})
+		}
+
+		minDepth := math.Log2(float64(count))
+		t.Logf("Height %d, min theorical depth %f", tree.Height(), minDepth)
+		assert.LessOrEqual(t, float64(tree.Height()), 2*minDepth)
+		assert.Equal(t, tree.Height(), tree.Depth()-1)
+		assert.Len(t, tree.QueryOverlaps(&AABBData{10.5, 0, 12.5, 1}), 3)
+	})
+
+	t.Run("Rebuild", func(t *testing.T) {
+		tree := NewTree()
+		objects := make([]*AABBData, 0, 1000)
+		for i := 0; i < 1000; i++ {
+			object := (&AABBData{0, 0, 1, 1}).Move(rand.Float64()*100, rand.Float64()*100)
+			objects = append(objects, object)
+			tree.Insert(object)
+		}
+		// Removing half of the objects leaves the tree in a worse shape than it'd be if it was built from scratch.
+		for _, object := range objects[:500] {
+			tree.Remove(object)
+		}
+		objects = objects[500:]
+
+		cost := tree.AreaCost()
+		tree.Rebuild()
+		t.Logf("Area cost %f before rebuilding, %f after", cost, tree.AreaCost())
+		assert.Less(t, tree.AreaCost(), cost)
+		assert.LessOrEqual(t, float64(tree.Height()), math.Ceil(math.Log2(500)))
+
+		query := &AABBData{25, 25, 75, 75}
+		found := tree.QueryOverlaps(query)
+		count := 0
+		for _, object := range objects {
+			if Overlaps(object, query) {
+				count++
+				assert.Contains(t, found, object)
+			}
+		}
+		assert.Len(t, found, count)
+
+		// The rebuilt tree can still be modified.
+		tree.Remove(objects[0])
+		tree.Insert(objects[0])
+		assert.Contains(t, tree.QueryOverlaps(objects[0].Move(0, 0)), objects[0])
+	})
+
+	t.Run("Empty tree", func(t *testing.T) {
+		tree := NewTree()
+		tree.Rebuild()
+		assert.Nil(t, tree.Root)
+		assert.Equal(t, 0, tree.Height())
+		assert.Equal(t, 0, tree.Depth())
+		assert.Equal(t, 0.0, tree.AreaCost())
+	})
+}
+
+func TestAABBTree_RayCast(t *testing.T) {
+	tree := NewTree()
+	objects := make([]*AABBData, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		object := (&AABBData{0, 0, 1, 1}).Move(rand.Float64()*100, rand.Float64()*100)
+		objects = append(objects, object)
+		tree.Insert(object)
+	}
+
+	t.Run("Matches brute force", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			x, y := rand.Float64()*100, rand.Float64()*100
+			dx, dy := rand.Float64()*100-50, rand.Float64()*100-50
+
+			found := []AABB{}
+			tree.RayCast(x, y, dx, dy, 1, func(object AABB, maxFraction float64) float64 {
+				found = append(found, object)
+				return maxFraction
+			})
+
+			count := 0
+			for _, object := range objects {
+				if _, hit := object.RayCast(x, y, dx, dy, 1); hit {
+					count++
+					assert.Contains(t, found, object)
+				}
+			}
+			assert.Len(t, found, count)
+		}
+	})
+
+	t.Run("Clipping finds the nearest object", func(t *testing.T) {
+		x, y, dx, dy := -10.0, 50.5, 200.0, 0.0
+
+		var nearest AABB
+		nearestFraction := math.Inf(1)
+		for _, object := range objects {
+			if fraction, hit := object.RayCast(x, y, dx, dy, 1); hit && fraction < nearestFraction {
+				nearest, nearestFraction = object, fraction
+			}
+		}
+
+		var found AABB
+		tree.RayCast(x, y, dx, dy, 1, func(object AABB, maxFraction float64) float64 {
+			fraction, _ := object.AABB().RayCast(x, y, dx, dy, maxFraction)
+			found = object
+			return fraction
+		})
+		assert.Equal(t, nearest, found)
+	})
+
+	t.Run("Terminating", func(t *testing.T) {
+		calls := 0
+		tree.RayCast(-10, 50.5, 200, 0, 1, func(object AABB, maxFraction float64) float64 {
+			calls++
+			return 0
+		})
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Slab test", func(t *testing.T) {
+		box := &AABBData{0, 0, 10, 10}
+
+		fraction, hit := box.RayCast(-10, 5, 20, 0, 1)
+		assert.True(t, hit)
+		assert.Equal(t, 0.5, fraction)
+
+		fraction, hit = box.RayCast(5, 5, 20, 0, 1)
+		assert.True(t, hit)
+		assert.Equal(t, 0.0, fraction)
+
+		_, hit = box.RayCast(-10, 5, 20, 0, 0.4)
+		assert.False(t, hit)
+		_, hit = box.RayCast(-10, 11, 20, 0, 1)
+		assert.False(t, hit)
+		_, hit = box.RayCast(-10, 5, -20, 0, 1)
+		assert.False(t, hit)
+
+		// Touching an edge counts as a hit.
+		_, hit = box.RayCast(-10, 10, 20, 0, 1)
+		assert.True(t, hit)
+	})
+}
+
+func TestAABBTree_QueryPairs(t *testing.T) {
+	randomObjects := func(count int) []*AABBData {
+		objects := make([]*AABBData, 0, count)
+		for i := 0; i < count; i++ {
+			objects = append(objects, (&AABBData{0, 0, 2, 2}).Move(rand.Float64()*100, rand.Float64()*100))
+		}
+		return objects
+	}
+
+	t.Run("All pairs", func(t *testing.T) {
+		tree := NewTree()
+		tree.Margin = 1
+		objects := randomObjects(500)
+		for _, object := range objects {
+			tree.Insert(object)
+		}
+
+		pairs := tree.QueryAllPairs()
+
+		seen := map[[2]AABB]bool{}
+		for _, pair := range pairs {
+			assert.True(t, Overlaps(pair.A, pair.B))
+			assert.False(t, seen[[2]AABB{pair.A, pair.B}] || seen[[2]AABB{pair.B, pair.A}], "pairs are returned once")
+			seen[[2]AABB{pair.A, pair.B}] = true
+		}
+
+		count := 0
+		for i, a := range objects {
+			for _, b := range objects[i+1:] {
+				if Overlaps(a, b) {
+					count++
+					assert.True(t, seen[[2]AABB{a, b}] || seen[[2]AABB{b, a}])
+				}
+			}
+		}
+		assert.Len(t, pairs, count)
+	})
+
+	t.Run("Tree against tree", func(t *testing.T) {
+		treeA := NewTree()
+		treeB := NewTree()
+		objectsA := randomObjects(300)
+		objectsB := randomObjects(300)
+		for _, object := range objectsA {
+			treeA.Insert(object)
+		}
+		for _, object := range objectsB {
+			treeB.Insert(object)
+		}
+		// Objects in both trees aren't paired with themselves.
+		treeB.Insert(objectsA[0])
+
+		pairs := treeA.QueryTreePairs(treeB)
+
+		count := 0
+		for _, a := range objectsA {
+			for _, b := range append(objectsB, objectsA[0]) {
+				if a != b && Overlaps(a, b) {
+					count++
+					assert.Contains(t, pairs, Pair{a, b})
+				}
+			}
+		}
+		assert.Len(t, pairs, count)
+	})
+
+	t.Run("Empty trees", func(t *testing.T) {
+		tree := NewTree()
+		assert.Empty(t, tree.QueryAllPairs())
+		assert.Empty(t, tree.QueryTreePairs(NewTree()))
+
+		tree.Insert(&AABBData{0, 0, 1, 1})
+		assert.Empty(t, tree.QueryAllPairs())
+	})
+}
+
+// valueAABB is an AABB that isn't a pointer.
+type valueAABB AABBData
+
+func (v valueAABB) AABB() *AABBData {
+	data := AABBData(v)
+	return &data
+}
+
+func TestAABBTree_Try(t *testing.T) {
+	tree := NewTree()
+	a := &AABBData{0, 0, 1, 1}
+
+	assert.NoError(t, tree.TryInsert(a))
+	assert.Equal(t, ErrAlreadyInTree, tree.TryInsert(a))
+	assert.Equal(t, ErrNotAReference, tree.TryInsert(valueAABB{0, 0, 1, 1}))
+
+	updated, err := tree.TryUpdate(a)
+	assert.False(t, updated)
+	assert.NoError(t, err)
+
+	assert.NoError(t, tree.TryRemove(a))
+	assert.Equal(t, ErrtNotInTree, tree.TryRemove(a))
+
+	_, err = tree.TryUpdate(a)
+	assert.Equal(t, ErrtNotInTree, err)
+}
+
+func TestAABBTree_QueryOverlapsFunc(t *testing.T) {
+	tree := NewTree()
+	for i := 0; i < 100; i++ {
+		tree.Insert((&AABBData{0, 0, 1, 1}).Move(float64(i%10), float64(i/10)))
+	}
+	query := &AABBData{2.5, 2.5, 6.5, 6.5}
+
+	t.Run("Stopping early", func(t *testing.T) {
+		visited := 0
+		tree.QueryOverlapsFunc(query, func(found AABB) bool {
+			visited++
+			return visited < 3
+		})
+		assert.Equal(t, 3, visited)
+	})
+
+	t.Run("Nested queries", func(t *testing.T) {
+		neighbours := 0
+		tree.QueryOverlapsFunc(query, func(found AABB) bool {
+			// Querying from within a callback doesn't disturb the outer query.
+			neighbours += len(tree.QueryOverlaps(Expand(found, 0.5)))
+			return true
+		})
+		assert.Len(t, tree.QueryOverlaps(query), 25)
+		assert.Equal(t, 25*9, neighbours)
+	})
+
+	t.Run("Reusing buffers", func(t *testing.T) {
+		dst := tree.QueryOverlapsInto(nil, query)
+		assert.Len(t, dst, 25)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			dst = tree.QueryOverlapsInto(dst[:0], query)
+		})
+		if !raceEnabled {
+			assert.Equal(t, 0.0, allocs)
+		}
+		assert.Len(t, dst, 25)
+	})
+
+	t.Run("Concurrent queries", func(t *testing.T) {
+		// Queries don't modify the tree, so they can run from several goroutines at once (see go test -race).
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					assert.Len(t, tree.QueryOverlaps(query), 25)
+					hits := 0
+					tree.RayCast(0.5, 0.5, 9, 0, 1, func(object AABB, maxFraction float64) float64 {
+						hits++
+						return maxFraction
+					})
+					assert.Equal(t, 10, hits)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestNewTreeFromObjects(t *testing.T) {
+	t.Run("Matches brute force", func(t *testing.T) {
+		objects := make([]AABB, 0, 1000)
+		for i := 0; i < 1000; i++ {
+			objects = append(objects, (&AABBData{0, 0, 1, 1}).Move(rand.Float64()*100, rand.Float64()*100))
+		}
+
+		tree, err := NewTreeFromObjects(objects)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, float64(tree.Height()), math.Ceil(math.Log2(1000)))
+
+		query := &AABBData{25, 25, 75, 75}
+		found := tree.QueryOverlaps(query)
+		count := 0
+		for _, object := range objects {
+			if Overlaps(object, query) {
+				count++
+				assert.Contains(t, found, object)
+			}
+		}
+		assert.Len(t, found, count)
+
+		// The tree can be modified as usual once it's built.
+		tree.Remove(objects[0])
+		assert.False(t, tree.Update(objects[1]))
+		assert.NoError(t, tree.TryInsert(objects[0]))
+	})
+
+	t.Run("Invalid objects", func(t *testing.T) {
+		a := &AABBData{0, 0, 1, 1}
+
+		_, err := NewTreeFromObjects([]AABB{a, &AABBData{2, 2, 3, 3}, a})
+		assert.Equal(t, ErrAlreadyInTree, err)
+
+		_, err = NewTreeFromObjects([]AABB{a, valueAABB{2, 2, 3, 3}})
+		assert.Equal(t, ErrNotAReference, err)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		tree, err := NewTreeFromObjects(nil)
+		assert.NoError(t, err)
+		assert.True(t, tree.IsEmpty())
+	})
+}
+
+func BenchmarkTreeBuild(b *testing.B) {
+	// A tilemap-like level: a grid of tiles, in order.
+	objects := make([]AABB, 0, 100*100)
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			objects = append(objects, &AABBData{float64(x) * 16, float64(y) * 16, float64(x+1) * 16, float64(y+1) * 16})
+		}
+	}
+
+	b.Run("Incremental", func(b *testing.B) {
+		var tree *Tree
+		for i := 0; i < b.N; i++ {
+			tree = NewTree()
+			for _, object := range objects {
+				tree.Insert(object)
+			}
+		}
+		b.ReportMetric(tree.AreaCost(), "area-cost")
+		b.ReportMetric(float64(tree.Height()), "height")
+	})
+
+	b.Run("Bulk", func(b *testing.B) {
+		var tree *Tree
+		for i := 0; i < b.N; i++ {
+			tree, _ = NewTreeFromObjects(objects)
+		}
+		b.ReportMetric(tree.AreaCost(), "area-cost")
+		b.ReportMetric(float64(tree.Height()), "height")
+	})
+}
@@ -1,7 +1,5 @@
 package resolv
 
-import "fmt"
-
 // A Circle represents an ordinary circle, and has a radius, in addition to normal shape properties.
 type Circle struct {
 	BasicShape
@@ -43,14 +41,14 @@ func (c *Circle) IsColliding(other Shape) bool {
 		return Distance(c.X, c.Y, closestX, closestY) <= c.Radius
 	case *Line:
 		return b.IsColliding(c)
+	case *ConvexPolygon:
+		return b.IsColliding(c)
 	case *Space:
 		return b.IsColliding(c)
 
 	}
 
-	fmt.Println("WARNING! Object ", other, " isn't a valid shape for collision testing against Circle ", c, "!")
-
-	return false
+	return collideCustom(c, other)
 
 }
 
@@ -1,5 +1,7 @@
 package resolv
 
+import "math"
+
 // Collision describes the collision found when a Shape attempted to resolve a movement into another Shape in an
 // isolated check, or when within the same Space as other existing Shapes.
 // ResolveX and ResolveY represent the displacement of the Shape to the point of collision. How far along the Shape
@@ -10,14 +12,63 @@ package resolv
 // when attempting to see if a movement would be ).
 // ShapeA is a pointer to the Shape that initiated the resolution check.
 // ShapeB is a pointer to the Shape that the colliding object collided with, if the Collision was successful.
+// Time is the fraction of the movement (from 0 to 1) that ShapeA could complete before coming into contact with ShapeB;
+// it's negative if ShapeA was embedded in ShapeB and had to be pushed backwards.
+// NormalX and NormalY are the unit contact normal; the direction pointing away from the surface of ShapeB that ShapeA
+// came into contact with (so for a Shape landing on the floor, it points upwards).
+// Depth is the penetration depth; how far ShapeA overlaps ShapeB along the normal at the position it attempted to move to.
+// Contacts are the points where ShapeA touches ShapeB once moved by ResolveX and ResolveY; each point's Shape is the Shape
+// it lies on.
 type Collision struct {
 	ResolveX, ResolveY float64
+	Time               float64
 	Teleporting        bool
 	ShapeA             Shape
 	ShapeB             Shape
+	NormalX, NormalY   float64
+	Depth              float64
+	Contacts           []IntersectionPoint
+}
+
+func (c *Collision) setContact(con contact) {
+	c.NormalX = con.normal.X
+	c.NormalY = con.normal.Y
+	c.Depth = con.depth
+	c.Contacts = con.points
 }
 
 // Colliding returns whether the Collision actually was valid because of a collision against another Shape.
 func (c *Collision) Colliding() bool {
 	return c.ShapeB != nil
 }
+
+// before returns whether the Collision happens before the other Collision along the same movement. Collisions happening at
+// the same Time are ordered by the distance from ShapeA to their first contact point, and then by the position of ShapeB.
+func (c *Collision) before(other Collision) bool {
+
+	if c.Time != other.Time {
+		return c.Time < other.Time
+	}
+
+	if dc, do := c.contactDistance(), other.contactDistance(); dc != do {
+		return dc < do
+	}
+
+	x, y := c.ShapeB.GetXY()
+	ox, oy := other.ShapeB.GetXY()
+
+	if x != ox {
+		return x < ox
+	}
+
+	return y < oy
+
+}
+
+func (c *Collision) contactDistance() float64 {
+	if len(c.Contacts) == 0 {
+		return math.Inf(1)
+	}
+	x, y := c.ShapeA.GetXY()
+	return Distance(x, y, c.Contacts[0].X, c.Contacts[0].Y)
+}
@@ -0,0 +1,196 @@
+package resolv
+
+import "math"
+
+// contact describes how two overlapping Shapes overlap; see the Collision struct for the meaning of the fields. Shape is
+// the second Shape, or the Shape within it that's overlapped, if it's a Space.
+type contact struct {
+	normal Point
+	depth  float64
+	points []IntersectionPoint
+	shape  Shape
+}
+
+// findContact returns the contact between the two Shapes provided, and whether they are overlapping. If either Shape is a
+// Space, the deepest contact between the Shapes contained within it and the other Shape is returned.
+func findContact(a, b Shape) (contact, bool) {
+
+	if spaceB, ok := b.(*Space); ok {
+		return deepestContact(*spaceB, func(member Shape) (contact, bool) {
+			if member == a || !canCollide(a, member) {
+				return contact{}, false
+			}
+			return findContact(a, member)
+		})
+	}
+
+	if spaceA, ok := a.(*Space); ok {
+		return deepestContact(*spaceA, func(member Shape) (contact, bool) {
+			if member == b {
+				return contact{}, false
+			}
+			return findContact(member, b)
+		})
+	}
+
+	hullA, okA := toHull(a)
+	hullB, okB := toHull(b)
+
+	if !okA || !okB || len(hullA.points) == 0 || len(hullB.points) == 0 {
+		return contact{}, false
+	}
+
+	normal, depth, overlapping := sat(hullA, hullB)
+
+	if !overlapping {
+		return contact{}, false
+	}
+
+	c := contact{normal: normal, depth: depth, shape: b}
+
+	for _, p := range contactPoints(hullA, hullB, normal) {
+		c.points = append(c.points, IntersectionPoint{X: p.X, Y: p.Y, Shape: b})
+	}
+
+	return c, true
+
+}
+
+func deepestContact(shapes Space, find func(Shape) (contact, bool)) (contact, bool) {
+
+	deepest := contact{}
+	found := false
+
+	for _, shape := range shapes {
+		if c, ok := find(shape); ok && (!found || c.depth > deepest.depth) {
+			deepest = c
+			found = true
+		}
+	}
+
+	return deepest, found
+
+}
+
+// contactPoints returns the points at which hull a, pushed out along normal, meets hull b. Circles touch at a single point;
+// for polygons, the edge of each hull that faces the other is found, and the edge of one is clipped against the edge of the
+// other (the reference edge) so that only the parts of it that lie behind the reference edge are left.
+func contactPoints(a, b hull, normal Point) []Point {
+
+	if len(b.points) == 1 {
+		return []Point{b.points[0].add(normal.scale(b.radius))}
+	}
+
+	if len(a.points) == 1 {
+		return []Point{a.points[0].sub(normal.scale(a.radius))}
+	}
+
+	refStart, refEnd, refNormal := facingEdge(a, normal.scale(-1))
+	incStart, incEnd, incNormal := facingEdge(b, normal)
+
+	// The reference edge is the one that is most perpendicular to the normal.
+	if incNormal.dot(normal) > refNormal.dot(normal.scale(-1)) {
+		refStart, refEnd, refNormal, incStart, incEnd = incStart, incEnd, incNormal, refStart, refEnd
+	}
+
+	tangent := refEnd.sub(refStart).normalized()
+
+	points := clipSegment([]Point{incStart, incEnd}, tangent, tangent.dot(refStart))
+	points = clipSegment(points, tangent.scale(-1), -tangent.dot(refEnd))
+
+	out := []Point{}
+	limit := refNormal.dot(refStart) + Tolerance
+
+	for _, p := range points {
+		if refNormal.dot(p) <= limit {
+			out = append(out, p)
+		}
+	}
+
+	if len(out) == 0 {
+		// Shouldn't happen with a valid normal, but the deepest point of b is a reasonable fallback.
+		deepest := b.points[0]
+		for _, p := range b.points[1:] {
+			if p.dot(normal) > deepest.dot(normal) {
+				deepest = p
+			}
+		}
+		out = append(out, deepest)
+	}
+
+	return out
+
+}
+
+// facingEdge returns the start and end of the edge of the hull whose outward normal is most aligned with the direction
+// provided, as well as that normal.
+func facingEdge(h hull, direction Point) (Point, Point, Point) {
+
+	best := math.Inf(-1)
+	var start, end, normal Point
+
+	for i := range h.points {
+		s := h.points[i]
+		e := h.points[(i+1)%len(h.points)]
+		n := e.sub(s).perp().normalized()
+		if d := n.dot(direction); d > best {
+			best = d
+			start, end, normal = s, e, n
+		}
+	}
+
+	return start, end, normal
+
+}
+
+// clipSegment returns the part of the segment made by the two points provided that lies on the side of the plane given by
+// normal and offset that the normal points towards.
+func clipSegment(points []Point, normal Point, offset float64) []Point {
+
+	if len(points) < 2 {
+		return points
+	}
+
+	d1 := normal.dot(points[0]) - offset
+	d2 := normal.dot(points[1]) - offset
+
+	out := []Point{}
+
+	if d1 >= 0 {
+		out = append(out, points[0])
+	}
+	if d2 >= 0 {
+		out = append(out, points[1])
+	}
+
+	if d1*d2 < 0 {
+		t := d1 / (d1 - d2)
+		out = append(out, points[0].add(points[1].sub(points[0]).scale(t)))
+	}
+
+	return out
+
+}
+
+// Penetration returns a Collision describing how the two Shapes provided overlap right now, without any movement involved.
+// If they overlap, ResolveX and ResolveY are the minimum translation vector (the smallest displacement that ShapeA
+// would have to move by to stop overlapping ShapeB), and the normal, depth, and contact points are filled out. If shapeB
+// is a Space, ShapeB is the Shape within it that shapeA overlaps the most. If they don't overlap, the returned Collision
+// isn't Colliding().
+func Penetration(shapeA, shapeB Shape) Collision {
+
+	out := Collision{ShapeA: shapeA}
+
+	c, ok := findContact(shapeA, shapeB)
+	if !ok {
+		return out
+	}
+
+	out.ShapeB = c.shape
+	out.setContact(c)
+	out.ResolveX = c.normal.X * c.depth
+	out.ResolveY = c.normal.Y * c.depth
+
+	return out
+
+}
@@ -0,0 +1,82 @@
+package resolv_test
+
+import (
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPenetration(t *testing.T) {
+	floor := NewRectangle(0, 100, 200, 16)
+
+	t.Run("Rectangle sunk into the floor", func(t *testing.T) {
+		box := NewRectangle(50, 90, 16, 16)
+		res := Penetration(box, floor)
+
+		assert.True(t, res.Colliding())
+		assert.Equal(t, 0.0, res.NormalX)
+		assert.Equal(t, -1.0, res.NormalY)
+		assert.Equal(t, 6.0, res.Depth)
+		assert.Equal(t, 0.0, res.ResolveX)
+		assert.Equal(t, -6.0, res.ResolveY)
+		assert.ElementsMatch(t, []IntersectionPoint{{X: 50, Y: 100, Shape: floor}, {X: 66, Y: 100, Shape: floor}}, res.Contacts)
+	})
+
+	t.Run("Rectangle pushed against a wall", func(t *testing.T) {
+		box := NewRectangle(196, 50, 16, 60)
+		res := Penetration(box, floor)
+
+		assert.True(t, res.Colliding())
+		assert.Equal(t, 1.0, res.NormalX)
+		assert.Equal(t, 0.0, res.NormalY)
+		assert.Equal(t, 4.0, res.Depth)
+	})
+
+	t.Run("Circle against a Circle", func(t *testing.T) {
+		a := NewCircle(0, 0, 4)
+		b := NewCircle(6, 0, 4)
+		res := Penetration(a, b)
+
+		assert.True(t, res.Colliding())
+		assert.Equal(t, -1.0, res.NormalX)
+		assert.Equal(t, 2.0, res.Depth)
+		assert.Equal(t, []IntersectionPoint{{X: 2, Y: 0, Shape: b}}, res.Contacts)
+	})
+
+	t.Run("Separated", func(t *testing.T) {
+		res := Penetration(NewRectangle(0, 0, 16, 16), floor)
+		assert.False(t, res.Colliding())
+	})
+
+	t.Run("Space", func(t *testing.T) {
+		space := NewSpace()
+		wall := NewRectangle(-16, 0, 16, 200)
+		space.Add(floor, wall)
+
+		box := NewRectangle(-2, 98, 16, 16)
+		res := Penetration(box, space)
+
+		assert.True(t, res.Colliding())
+		assert.Equal(t, floor, res.ShapeB)
+		assert.Equal(t, 14.0, res.Depth)
+		assert.Equal(t, floor, res.Contacts[0].Shape)
+	})
+}
+
+func TestResolve_Contact(t *testing.T) {
+	floor := NewRectangle(0, 100, 200, 16)
+	box := NewRectangle(50, 80, 16, 16)
+
+	res := Resolve(box, floor, 0, 10)
+	assert.True(t, res.Colliding())
+	assert.Equal(t, -1.0, res.NormalY)
+	assert.Equal(t, 6.0, res.Depth)
+	assert.Len(t, res.Contacts, 2)
+
+	wall := NewLine(80, 0, 80, 200)
+	res = Resolve(box, wall, 20, 0)
+	assert.True(t, res.Colliding())
+	assert.Equal(t, -1.0, res.NormalX)
+	assert.InDelta(t, 6.0, res.Depth, 1e-9)
+}
@@ -0,0 +1,257 @@
+package resolv
+
+import "math"
+
+// Resolver is anything that can resolve the movement of a Shape against the Shapes it holds, finding every Shape it would
+// come into contact with, sorted by Time, like a Space or an IndexedSpace.
+type Resolver interface {
+	ResolveAll(checkingShape Shape, deltaX, deltaY float64) []Collision
+}
+
+/*CharacterController moves a Shape through a Space (or any other Resolver) the way the character of a platformer usually
+moves; it slides along the walls and floors it runs into instead of stopping dead, walks up and down slopes that aren't too
+steep, climbs steps that are low enough, and sticks to the ground when walking down slopes or off small ledges. After each
+Move(), it reports whether the Shape ended up on the ground, against a wall, or against a ceiling.
+
+Directions are given relative to UpX and UpY, which point up the screen (0, -1) by default. The controller doesn't keep any
+velocity; gravity, friction, and jumping are up to the caller, who passes the movement for each frame to Move().
+
+One-way Shapes (see BasicShape.OneWayX) can be dropped through by calling DropThrough() before moving down.*/
+type CharacterController struct {
+	Shape Shape
+	World Resolver
+	// UpX and UpY are the unit vector pointing "up", away from the ground.
+	UpX, UpY float64
+	// MaxSlope is the steepest slope, in radians from flat ground, that counts as ground, which can be walked up. Steeper
+	// surfaces are walls. It's 45 degrees by default.
+	MaxSlope float64
+	// StepHeight is the height of the tallest step the Shape climbs on its own when walking into it while on the ground.
+	StepHeight float64
+	// SnapDistance is how far down the Shape is pulled to keep it on the ground, when it was on the ground before moving
+	// and isn't moving up.
+	SnapDistance float64
+	// MaxSlides is how many times a single Move() can change direction by sliding along the surfaces it hits.
+	MaxSlides int
+
+	grounded, onWall, onCeiling bool
+	ground                      Shape
+	groundNormal                Point
+	dropThrough                 bool
+}
+
+// NewCharacterController returns a CharacterController that moves the Shape provided through the world provided, with a
+// MaxSlope of 45 degrees, and no step climbing or ground snapping.
+func NewCharacterController(shape Shape, world Resolver) *CharacterController {
+	return &CharacterController{
+		Shape:     shape,
+		World:     world,
+		UpX:       0,
+		UpY:       -1,
+		MaxSlope:  math.Pi / 4,
+		MaxSlides: 4,
+	}
+}
+
+// IsGrounded returns whether the Shape was standing on the ground at the end of the last Move().
+func (cc *CharacterController) IsGrounded() bool {
+	return cc.grounded
+}
+
+// IsOnWall returns whether the Shape ran into a wall (a surface too steep to walk on) during the last Move().
+func (cc *CharacterController) IsOnWall() bool {
+	return cc.onWall
+}
+
+// IsOnCeiling returns whether the Shape ran into a ceiling during the last Move().
+func (cc *CharacterController) IsOnCeiling() bool {
+	return cc.onCeiling
+}
+
+// Ground returns the Shape that the Shape was standing on at the end of the last Move(), or nil if it isn't grounded.
+func (cc *CharacterController) Ground() Shape {
+	return cc.ground
+}
+
+// GroundNormal returns the normal of the ground the Shape was standing on at the end of the last Move(), or 0, 0 if it
+// isn't grounded.
+func (cc *CharacterController) GroundNormal() (float64, float64) {
+	return cc.groundNormal.X, cc.groundNormal.Y
+}
+
+// DropThrough makes the next Move() pass through one-way Shapes, so that the Shape can drop down through a one-way
+// platform it's standing on. Once the Shape overlaps the platform, it keeps falling through it on its own.
+func (cc *CharacterController) DropThrough() {
+	cc.dropThrough = true
+}
+
+// Move moves the Shape by dx and dy, sliding along whatever it runs into, and returns how far it actually moved.
+func (cc *CharacterController) Move(dx, dy float64) (float64, float64) {
+
+	up := Point{cc.UpX, cc.UpY}
+	wasGrounded := cc.grounded
+
+	cc.grounded, cc.onWall, cc.onCeiling = false, false, false
+	cc.ground, cc.groundNormal = nil, Point{}
+	defer func() { cc.dropThrough = false }()
+
+	moved := Point{}
+	remaining := Point{dx, dy}
+
+	for i := 0; i < cc.MaxSlides && remaining != (Point{}); i++ {
+
+		step, res := cc.sweep(remaining)
+		moved = moved.add(cc.move(step))
+
+		if !res.Colliding() {
+			break
+		}
+
+		left := remaining.sub(step)
+		normal := Point{res.NormalX, res.NormalY}
+
+		switch cc.surface(normal) {
+
+		case surfaceGround:
+			cc.setGround(res.ShapeB, normal)
+			remaining = alongGround(left, normal, up)
+
+		case surfaceCeiling:
+			cc.onCeiling = true
+			remaining = slide(left, normal)
+
+		default:
+			if (wasGrounded || cc.grounded) && cc.StepHeight > 0 {
+				if step, ok := cc.stepUp(left, up); ok {
+					moved = moved.add(step)
+					remaining = Point{}
+					break
+				}
+			}
+			cc.onWall = true
+			remaining = slide(left, normal)
+			// Walls can't be climbed by sliding up them.
+			if (wasGrounded || cc.grounded) && remaining.dot(up) > 0 {
+				remaining = remaining.sub(up.scale(remaining.dot(up)))
+			}
+
+		}
+
+	}
+
+	if wasGrounded && !cc.grounded && !cc.dropThrough && cc.SnapDistance > 0 && moved.dot(up) <= 0 {
+		drop, res := cc.sweep(up.scale(-cc.SnapDistance))
+		if normal := (Point{res.NormalX, res.NormalY}); res.Colliding() && cc.surface(normal) == surfaceGround {
+			moved = moved.add(cc.move(drop))
+			cc.setGround(res.ShapeB, normal)
+		}
+	}
+
+	return moved.X, moved.Y
+
+}
+
+// stepUp tries to climb a step by moving up by StepHeight, then on by the horizontal part of the movement left, and then
+// back down onto the ground. If the Shape doesn't make any headway or doesn't land on the ground, it's put back where it
+// was.
+func (cc *CharacterController) stepUp(left, up Point) (Point, bool) {
+
+	forward := left.sub(up.scale(left.dot(up)))
+	if forward.length() < Tolerance {
+		return Point{}, false
+	}
+
+	moved := Point{}
+	revert := func() (Point, bool) {
+		cc.move(moved.scale(-1))
+		return Point{}, false
+	}
+
+	rise, _ := cc.sweep(up.scale(cc.StepHeight))
+	moved = moved.add(cc.move(rise))
+	height := moved.dot(up)
+
+	progress, _ := cc.sweep(forward)
+	moved = moved.add(cc.move(progress))
+
+	if progress.length() < Tolerance {
+		return revert()
+	}
+
+	drop, land := cc.sweep(up.scale(-height))
+	normal := Point{land.NormalX, land.NormalY}
+
+	if !land.Colliding() || cc.surface(normal) != surfaceGround {
+		return revert()
+	}
+
+	moved = moved.add(cc.move(drop))
+	cc.setGround(land.ShapeB, normal)
+
+	return moved, true
+
+}
+
+// sweep returns how far the Shape can move along the delta before running into something, and the Collision with what
+// it runs into, if anything.
+func (cc *CharacterController) sweep(delta Point) (Point, Collision) {
+	for _, res := range cc.World.ResolveAll(cc.Shape, delta.X, delta.Y) {
+		if _, oneWay := isOneWay(res.ShapeB); oneWay && cc.dropThrough {
+			continue
+		}
+		return Point{res.ResolveX, res.ResolveY}, res
+	}
+	return delta, Collision{}
+}
+
+func (cc *CharacterController) move(delta Point) Point {
+	cc.Shape.Move(delta.X, delta.Y)
+	return delta
+}
+
+func (cc *CharacterController) setGround(ground Shape, normal Point) {
+	cc.grounded = true
+	cc.ground = ground
+	cc.groundNormal = normal
+}
+
+type surfaceType int
+
+const (
+	surfaceWall surfaceType = iota
+	surfaceGround
+	surfaceCeiling
+)
+
+// surface classifies a surface by its normal; it's ground if the normal is within MaxSlope of up, a ceiling if it's
+// within MaxSlope of down, and a wall otherwise.
+func (cc *CharacterController) surface(normal Point) surfaceType {
+	limit := math.Cos(cc.MaxSlope) - epsilon
+	facing := normal.dot(Point{cc.UpX, cc.UpY})
+	switch {
+	case facing >= limit:
+		return surfaceGround
+	case -facing >= limit:
+		return surfaceCeiling
+	}
+	return surfaceWall
+}
+
+// alongGround redirects the horizontal part of the movement along the ground with the normal provided, so that walking on
+// a slope keeps the same horizontal speed as walking on flat ground. The vertical part (like gravity) is dropped, so the
+// Shape doesn't slide down slopes it stands on.
+func alongGround(movement, normal, up Point) Point {
+
+	horizontal := movement.sub(up.scale(movement.dot(up)))
+	if horizontal.length() < Tolerance {
+		return Point{}
+	}
+
+	tangent := normal.perp()
+	if tangent.dot(horizontal) < 0 {
+		tangent = tangent.scale(-1)
+	}
+
+	// Scale the tangent so that its horizontal part matches the horizontal movement.
+	return tangent.scale(horizontal.length() / tangent.dot(horizontal.normalized()))
+
+}
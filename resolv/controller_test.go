@@ -0,0 +1,168 @@
+package resolv_test
+
+import (
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharacterController(t *testing.T) {
+
+	newWorld := func(shapes ...Shape) (*Rectangle, *CharacterController) {
+		space := NewSpace()
+		player := NewRectangle(0, 0, 16, 16)
+		space.Add(player)
+		space.Add(shapes...)
+		return player, NewCharacterController(player, space)
+	}
+
+	t.Run("Landing", func(t *testing.T) {
+		floor := NewRectangle(-100, 32, 200, 16)
+		player, cc := newWorld(floor)
+
+		_, dy := cc.Move(0, 10)
+		assert.Equal(t, 10.0, dy)
+		assert.False(t, cc.IsGrounded())
+
+		_, dy = cc.Move(0, 10)
+		assert.Equal(t, 6.0, dy)
+		assert.True(t, cc.IsGrounded())
+		assert.Equal(t, floor, cc.Ground())
+		assert.Equal(t, 16.0, player.Y)
+
+		// Standing still, gravity keeps the Shape grounded without moving it.
+		_, dy = cc.Move(0, 1)
+		assert.Equal(t, 0.0, dy)
+		assert.True(t, cc.IsGrounded())
+	})
+
+	t.Run("Sliding along walls", func(t *testing.T) {
+		wall := NewRectangle(20, -100, 16, 200)
+		player, cc := newWorld(wall)
+
+		dx, dy := cc.Move(10, 10)
+		assert.Equal(t, 4.0, dx)
+		assert.Equal(t, 10.0, dy)
+		assert.True(t, cc.IsOnWall())
+		assert.False(t, cc.IsGrounded())
+		assert.Equal(t, 4.0, player.X)
+	})
+
+	t.Run("Ceilings", func(t *testing.T) {
+		ceiling := NewRectangle(-100, -20, 200, 16)
+		_, cc := newWorld(ceiling)
+
+		dx, dy := cc.Move(3, -8)
+		assert.Equal(t, 3.0, dx)
+		assert.Equal(t, -4.0, dy)
+		assert.True(t, cc.IsOnCeiling())
+	})
+
+	t.Run("Walking up slopes", func(t *testing.T) {
+		// A 30 degree-ish slope rising to the right, starting under the Shape's bottom right corner.
+		floor := NewRectangle(-100, 16, 116, 16)
+		slope := NewConvexPolygon(16, 16, 0, 0, 100, -50, 100, 0)
+		player, cc := newWorld(floor, slope)
+
+		cc.Move(0, 1)
+		assert.True(t, cc.IsGrounded())
+
+		for i := 0; i < 10; i++ {
+			cc.Move(2, 1)
+			assert.True(t, cc.IsGrounded())
+		}
+		assert.InDelta(t, 20.0, player.X, 1e-6)
+		assert.InDelta(t, -10.0, player.Y, 1e-6)
+		assert.Equal(t, slope, cc.Ground())
+		assert.False(t, cc.IsOnWall())
+	})
+
+	t.Run("Steep slopes are walls", func(t *testing.T) {
+		floor := NewRectangle(-100, 16, 116, 16)
+		slope := NewConvexPolygon(16, 16, 0, 0, 20, -50, 20, 0)
+		player, cc := newWorld(floor, slope)
+
+		cc.Move(0, 1)
+		for i := 0; i < 10; i++ {
+			cc.Move(2, 1)
+		}
+		assert.True(t, cc.IsOnWall())
+		assert.True(t, cc.IsGrounded())
+		assert.Equal(t, 0.0, player.Y)
+		assert.Less(t, player.X, 1.0)
+	})
+
+	t.Run("Steps", func(t *testing.T) {
+		floor := NewRectangle(-100, 16, 200, 16)
+		step := NewRectangle(20, 10, 80, 6)
+		player, cc := newWorld(floor, step)
+		cc.StepHeight = 8
+
+		cc.Move(0, 1)
+		cc.Move(8, 1)
+		assert.True(t, cc.IsGrounded())
+		assert.Equal(t, step, cc.Ground())
+		assert.Equal(t, 8.0, player.X)
+		assert.Equal(t, -6.0, player.Y)
+
+		// Steps taller than StepHeight block the way.
+		player.SetXY(0, 0)
+		cc.StepHeight = 4
+		cc.Move(0, 1)
+		cc.Move(8, 1)
+		assert.True(t, cc.IsOnWall())
+		assert.Equal(t, 4.0, player.X)
+		assert.Equal(t, 0.0, player.Y)
+	})
+
+	t.Run("Snapping to the ground", func(t *testing.T) {
+		// A slope falling to the right.
+		floor := NewRectangle(-100, 16, 100, 16)
+		slope := NewConvexPolygon(0, 16, 0, 0, 100, 50, 0, 50)
+		player, cc := newWorld(floor, slope)
+		cc.SnapDistance = 4
+
+		player.X = -16
+		cc.Move(0, 1)
+		for i := 0; i < 10; i++ {
+			cc.Move(2, 0)
+			assert.True(t, cc.IsGrounded())
+		}
+		assert.Equal(t, slope, cc.Ground())
+		assert.InDelta(t, 4.0, player.X, 1e-6)
+		assert.InDelta(t, 2.0, player.Y, 1e-6)
+
+		// Moving up (jumping) doesn't snap.
+		cc.Move(0, -2)
+		assert.False(t, cc.IsGrounded())
+	})
+}
+
+func TestCharacterController_DropThrough(t *testing.T) {
+	space := NewSpace()
+	player := NewRectangle(0, 0, 16, 16)
+	platform := NewRectangle(-100, 16, 200, 4)
+	platform.SetOneWay(0, -1)
+	floor := NewRectangle(-100, 64, 200, 16)
+	space.Add(player, platform, floor)
+
+	cc := NewCharacterController(player, space)
+	cc.SnapDistance = 4
+
+	cc.Move(0, 2)
+	assert.True(t, cc.IsGrounded())
+	assert.Equal(t, platform, cc.Ground())
+
+	cc.DropThrough()
+	cc.Move(0, 2)
+	assert.False(t, cc.IsGrounded())
+	assert.Equal(t, 2.0, player.Y)
+
+	// Once inside the platform, the Shape keeps falling through it.
+	for i := 0; i < 30 && !cc.IsGrounded(); i++ {
+		cc.Move(0, 2)
+	}
+	assert.Equal(t, floor, cc.Ground())
+	assert.Equal(t, 48.0, player.Y)
+}
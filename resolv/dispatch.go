@@ -0,0 +1,164 @@
+package resolv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	// ErrUnsupportedShapes is returned when two Shapes can't be tested against each other, because at least one of them is
+	// a custom Shape type and no CollisionHandler has been registered for the pair.
+	ErrUnsupportedShapes = errors.New("no collision test available for these shapes")
+	// ErrSpaceAddsItself is returned when attempting to add a Space to itself.
+	ErrSpaceAddsItself = errors.New("a Space cannot add itself")
+)
+
+// CollisionHandler tests whether two Shapes are colliding.
+type CollisionHandler func(a, b Shape) bool
+
+var (
+	// handlersLock guards collisionHandlers and unsupportedCollisionHook, so that handlers can be registered while other
+	// goroutines are testing for collisions.
+	handlersLock             sync.RWMutex
+	collisionHandlers        = map[[2]reflect.Type]CollisionHandler{}
+	unsupportedCollisionHook func(err error)
+)
+
+// SetUnsupportedCollisionHook sets a function to be called with an error wrapping ErrUnsupportedShapes whenever one of the
+// built-in Shapes is tested against a Shape that it doesn't know how to test against, and no CollisionHandler has been
+// registered for the pair. In these cases, IsColliding() returns false. There's no hook by default, so nothing is
+// reported; setting it to nil removes it again. It's safe to call while other goroutines are testing for collisions.
+func SetUnsupportedCollisionHook(hook func(err error)) {
+	handlersLock.Lock()
+	defer handlersLock.Unlock()
+	unsupportedCollisionHook = hook
+}
+
+// RegisterCollisionHandler registers the handler provided to test for collisions between Shapes of the types of a and b.
+// The arguments are only used for their types, so nil pointers can be passed (e.g. (*MyShape)(nil)). The handler is used
+// for the reverse pair as well, with the Shapes swapped so that its first argument is always of the type of a.
+// Registering a handler for a pair of types that already has one replaces it; registering a nil handler removes it.
+//
+// The built-in Shapes use the registered handlers when they're tested against a custom Shape type, and so does
+// TryIsColliding(). Handlers can be registered and removed while other goroutines are testing for collisions.
+func RegisterCollisionHandler(a, b Shape, handler CollisionHandler) {
+
+	key := [2]reflect.Type{reflect.TypeOf(a), reflect.TypeOf(b)}
+
+	handlersLock.Lock()
+	defer handlersLock.Unlock()
+
+	if handler == nil {
+		delete(collisionHandlers, key)
+		return
+	}
+
+	collisionHandlers[key] = handler
+
+}
+
+// runHandler runs the registered CollisionHandler for the pair of Shapes provided, returning its result and true, or
+// false if there's no handler for the pair. The handler is run without holding the lock, so that it can test for other
+// collisions, or register handlers, itself.
+func runHandler(a, b Shape) (bool, bool) {
+
+	typeA := reflect.TypeOf(a)
+	typeB := reflect.TypeOf(b)
+
+	handlersLock.RLock()
+	handler, ok := collisionHandlers[[2]reflect.Type{typeA, typeB}]
+	reversed, reversedOK := collisionHandlers[[2]reflect.Type{typeB, typeA}]
+	handlersLock.RUnlock()
+
+	if ok {
+		return handler(a, b), true
+	}
+
+	if reversedOK {
+		return reversed(b, a), true
+	}
+
+	return false, false
+
+}
+
+// isBuiltin returns whether the Shape is one of the Shapes that resolv provides.
+func isBuiltin(shape Shape) bool {
+	switch shape.(type) {
+	case *Rectangle, *Circle, *Line, *ConvexPolygon, *Space:
+		return true
+	}
+	return false
+}
+
+func unsupportedError(a, b Shape) error {
+	return fmt.Errorf("%w: %T and %T", ErrUnsupportedShapes, a, b)
+}
+
+// collideCustom tests a built-in Shape against a Shape that it doesn't know about, using the registered CollisionHandler
+// for the pair. If there isn't one, it's reported through the hook set with SetUnsupportedCollisionHook().
+func collideCustom(a, b Shape) bool {
+
+	if colliding, ok := runHandler(a, b); ok {
+		return colliding
+	}
+
+	handlersLock.RLock()
+	hook := unsupportedCollisionHook
+	handlersLock.RUnlock()
+
+	if hook != nil {
+		hook(unsupportedError(a, b))
+	}
+
+	return false
+
+}
+
+// TryIsColliding returns whether Shape a is colliding with Shape b, like a.IsColliding(b), but returns an error wrapping
+// ErrUnsupportedShapes instead of quietly returning false if the Shapes can't be tested against each other. A registered
+// CollisionHandler for the pair is used if there is one; otherwise both Shapes need to be built-in Shapes. Spaces are tested
+// Shape by Shape.
+func TryIsColliding(a, b Shape) (bool, error) {
+
+	if colliding, ok := runHandler(a, b); ok {
+		return colliding, nil
+	}
+
+	if spaceA, ok := a.(*Space); ok {
+		return tryAny(*spaceA, func(member Shape) (bool, error) {
+			if member == b {
+				return false, nil
+			}
+			return TryIsColliding(member, b)
+		})
+	}
+
+	if spaceB, ok := b.(*Space); ok {
+		return tryAny(*spaceB, func(member Shape) (bool, error) {
+			if member == a || !canCollide(a, member) {
+				return false, nil
+			}
+			return TryIsColliding(a, member)
+		})
+	}
+
+	if isBuiltin(a) && isBuiltin(b) {
+		return a.IsColliding(b), nil
+	}
+
+	return false, unsupportedError(a, b)
+
+}
+
+func tryAny(shapes Space, test func(Shape) (bool, error)) (bool, error) {
+	for _, shape := range shapes {
+		colliding, err := test(shape)
+		if err != nil || colliding {
+			return colliding, err
+		}
+	}
+	return false, nil
+}
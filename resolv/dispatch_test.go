@@ -0,0 +1,139 @@
+package resolv_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv"
+	"github.com/stretchr/testify/assert"
+)
+
+// point is a custom Shape that's only a position.
+type point struct {
+	BasicShape
+}
+
+func newPoint(x, y float64) *point {
+	p := &point{}
+	p.X = x
+	p.Y = y
+	return p
+}
+
+func (p *point) IsColliding(other Shape) bool {
+	colliding, _ := TryIsColliding(p, other)
+	return colliding
+}
+
+func (p *point) WouldBeColliding(other Shape, dx, dy float64) bool {
+	p.Move(dx, dy)
+	colliding := p.IsColliding(other)
+	p.Move(-dx, -dy)
+	return colliding
+}
+
+func (p *point) GetBoundingRect() *Rectangle {
+	return NewRectangle(p.X, p.Y, 0, 0)
+}
+
+func TestCollisionHandlers(t *testing.T) {
+	reported := []error{}
+	SetUnsupportedCollisionHook(func(err error) {
+		reported = append(reported, err)
+	})
+	defer SetUnsupportedCollisionHook(nil)
+
+	rect := NewRectangle(0, 0, 16, 16)
+	p := newPoint(8, 8)
+
+	t.Run("Unsupported pair", func(t *testing.T) {
+		assert.False(t, rect.IsColliding(p))
+		assert.False(t, NewCircle(8, 8, 4).IsColliding(p))
+		assert.Len(t, reported, 2)
+		assert.True(t, errors.Is(reported[0], ErrUnsupportedShapes))
+
+		_, err := TryIsColliding(rect, p)
+		assert.True(t, errors.Is(err, ErrUnsupportedShapes))
+	})
+
+	RegisterCollisionHandler((*point)(nil), (*Rectangle)(nil), func(a, b Shape) bool {
+		pt := a.(*point)
+		r := b.(*Rectangle)
+		return pt.X >= r.X && pt.Y >= r.Y && pt.X < r.X+r.W && pt.Y < r.Y+r.H
+	})
+	defer RegisterCollisionHandler((*point)(nil), (*Rectangle)(nil), nil)
+
+	t.Run("Registered pair", func(t *testing.T) {
+		reported = reported[:0]
+
+		assert.True(t, rect.IsColliding(p))
+		assert.True(t, p.IsColliding(rect))
+
+		colliding, err := TryIsColliding(rect, p)
+		assert.True(t, colliding)
+		assert.NoError(t, err)
+
+		p.X = 20
+		assert.False(t, rect.IsColliding(p))
+		assert.Empty(t, reported)
+	})
+
+	t.Run("Space", func(t *testing.T) {
+		space := NewSpace()
+		space.Add(NewRectangle(100, 100, 16, 16), rect)
+
+		p.X = 8
+		assert.True(t, space.IsColliding(p))
+
+		colliding, err := TryIsColliding(space, p)
+		assert.True(t, colliding)
+		assert.NoError(t, err)
+
+		res := Resolve(p, space, 100, 100)
+		assert.True(t, res.Colliding())
+
+		_, err = TryIsColliding(p, NewCircle(0, 0, 4))
+		assert.True(t, errors.Is(err, ErrUnsupportedShapes))
+	})
+}
+
+func TestCollisionHandlers_Concurrent(t *testing.T) {
+	rect := NewRectangle(0, 0, 16, 16)
+	p := newPoint(8, 8)
+	defer RegisterCollisionHandler((*point)(nil), (*Rectangle)(nil), nil)
+	defer SetUnsupportedCollisionHook(nil)
+
+	// Handlers can be registered and removed while other goroutines test for collisions (see go test -race).
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				rect.IsColliding(p)
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		RegisterCollisionHandler((*point)(nil), (*Rectangle)(nil), func(a, b Shape) bool { return true })
+		SetUnsupportedCollisionHook(func(err error) {})
+		RegisterCollisionHandler((*point)(nil), (*Rectangle)(nil), nil)
+		SetUnsupportedCollisionHook(nil)
+	}
+
+	wg.Wait()
+
+	_, err := TryIsColliding(rect, p)
+	assert.True(t, errors.Is(err, ErrUnsupportedShapes), "the handler is unregistered afterwards")
+}
+
+func TestSpace_AddItself(t *testing.T) {
+	space := NewSpace()
+	rect := NewRectangle(0, 0, 16, 16)
+
+	err := space.Add(space, rect)
+	assert.Equal(t, ErrSpaceAddsItself, err)
+	assert.Equal(t, Space{rect}, *space)
+}
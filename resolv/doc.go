@@ -19,5 +19,11 @@ space, and so there aren't any units of measurement to remember when using Space
 Shapes, Spaces are simple, but also very powerful. Spaces allow you to easily check for collision
 with, and resolve collision against multiple Shapes within that Space. A Space being just a
 collection of Shapes means that you can manipulate and filter them as necessary.
+
+For levels with a large number of Shapes, an IndexedSpace can be used instead of a Space. It keeps
+its Shapes in an AABB tree (see the aabb package), so that collision checks only need to test the
+Shapes that are near the checking Shape. Levels that are dense with Shapes of about the same size can
+use a spatial hash instead, and those where most Shapes move along the X axis a sweep and prune,
+through NewIndexedSpaceWithBroadphase().
 */
 package resolv
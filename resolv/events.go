@@ -0,0 +1,171 @@
+package resolv
+
+// ContactEventType is the kind of change a ContactEvent reports.
+type ContactEventType int
+
+const (
+	// ContactEnter means the Shapes started overlapping since the last step.
+	ContactEnter ContactEventType = iota
+	// ContactStay means the Shapes were already overlapping at the last step, and still are.
+	ContactStay
+	// ContactExit means the Shapes were overlapping at the last step, but no longer are.
+	ContactExit
+)
+
+func (t ContactEventType) String() string {
+	switch t {
+	case ContactEnter:
+		return "Enter"
+	case ContactStay:
+		return "Stay"
+	case ContactExit:
+		return "Exit"
+	}
+	return "Unknown"
+}
+
+// ContactEvent reports a change in whether a pair of Shapes is overlapping. ShapeA comes before ShapeB in the Space that
+// was stepped (when the pair was first found).
+type ContactEvent struct {
+	Type           ContactEventType
+	ShapeA, ShapeB Shape
+}
+
+/*ContactTracker keeps track of which Shapes in a Space are overlapping from one step to the next, so that trigger zones and
+the like can react to Shapes entering, staying within, and leaving them without having to remember what they were
+touching during the previous frame. Call Step() with the Space once per frame; it reports each pair of overlapping Shapes
+through the OnEnter, OnStay, and OnExit callbacks, and also returns the events as a list. Step() tests every pair of
+Shapes in the Space; for large levels, StepIndexed() can be called with an IndexedSpace instead, so that only the pairs
+of Shapes that its broadphase finds close to each other are tested.
+
+A pair of Shapes only takes part if both of them pass the Filter (if one is set), and if each of them selects the other's
+layer with its mask (see BasicShape.Mask; the default mask of 0 selects every layer), so a trigger zone whose mask only
+selects the player's layer reports the player, but not the level's walls. Spaces within the Space being stepped are treated as a single Shape.*/
+type ContactTracker struct {
+	// OnEnter, if set, is called for each pair of Shapes that started overlapping.
+	OnEnter func(a, b Shape)
+	// OnStay, if set, is called for each pair of Shapes that were overlapping at the last step and still are.
+	OnStay func(a, b Shape)
+	// OnExit, if set, is called for each pair of Shapes that stopped overlapping (including because one of them was
+	// removed from the Space).
+	OnExit func(a, b Shape)
+	// Filter, if set, decides which Shapes take part; Shapes that it returns false for are ignored.
+	Filter func(Shape) bool
+
+	pairs map[[2]Shape]bool
+	// order holds the keys of pairs in the order they were found, so that events are reported in a consistent order.
+	order [][2]Shape
+}
+
+// NewContactTracker returns a new ContactTracker, which hasn't seen any overlapping Shapes yet.
+func NewContactTracker() *ContactTracker {
+	return &ContactTracker{pairs: map[[2]Shape]bool{}}
+}
+
+// Step finds the pairs of overlapping Shapes in the Space and compares them to the pairs found by the previous step,
+// calling the tracker's callbacks and returning a ContactEvent for each pair that's overlapping now or was before. Enter
+// and Stay events come first, in the order of the Shapes in the Space, followed by the Exit events, in the order the
+// pairs were found by the previous step.
+func (ct *ContactTracker) Step(space *Space) []ContactEvent {
+
+	shapes := *space
+	if ct.Filter != nil {
+		shapes = *space.Filter(ct.Filter)
+	}
+
+	return ct.step(func(visit func(a, b Shape)) {
+		for i, a := range shapes {
+			for _, b := range shapes[i+1:] {
+				visit(a, b)
+			}
+		}
+	})
+
+}
+
+// StepIndexed works like Step(), but finds the pairs of overlapping Shapes in the IndexedSpace provided, only testing the
+// pairs of Shapes whose bounding rectangles its broadphase finds overlapping. The events come in the same order as they
+// would if the IndexedSpace's Shapes were stepped as a Space.
+func (ct *ContactTracker) StepIndexed(space *IndexedSpace) []ContactEvent {
+
+	return ct.step(func(visit func(a, b Shape)) {
+		space.candidatePairs(func(a, b Shape) {
+			if ct.Filter == nil || (ct.Filter(a) && ct.Filter(b)) {
+				visit(a, b)
+			}
+		})
+	})
+
+}
+
+// step tests the candidate pairs of Shapes that the function provided passes along, in order, and compares the pairs that
+// are overlapping to those found by the previous step.
+func (ct *ContactTracker) step(candidates func(visit func(a, b Shape))) []ContactEvent {
+
+	if ct.pairs == nil {
+		ct.pairs = map[[2]Shape]bool{}
+	}
+
+	events := []ContactEvent{}
+	current := map[[2]Shape]bool{}
+	order := [][2]Shape{}
+
+	candidates(func(a, b Shape) {
+
+		if a == b || !canCollide(a, b) || !canCollide(b, a) || !a.IsColliding(b) {
+			return
+		}
+
+		key := [2]Shape{a, b}
+		if ct.pairs[[2]Shape{b, a}] {
+			// Keep the order the pair was first found in.
+			key = [2]Shape{b, a}
+		}
+		current[key] = true
+		order = append(order, key)
+
+		if ct.pairs[key] {
+			events = append(events, ContactEvent{ContactStay, key[0], key[1]})
+		} else {
+			events = append(events, ContactEvent{ContactEnter, key[0], key[1]})
+		}
+
+	})
+
+	for _, key := range ct.order {
+		if !current[key] {
+			events = append(events, ContactEvent{ContactExit, key[0], key[1]})
+		}
+	}
+
+	ct.pairs = current
+	ct.order = order
+
+	for _, event := range events {
+		callback := ct.OnStay
+		switch event.Type {
+		case ContactEnter:
+			callback = ct.OnEnter
+		case ContactExit:
+			callback = ct.OnExit
+		}
+		if callback != nil {
+			callback(event.ShapeA, event.ShapeB)
+		}
+	}
+
+	return events
+
+}
+
+// IsTouching returns whether the two Shapes were overlapping as of the last step, in either order.
+func (ct *ContactTracker) IsTouching(a, b Shape) bool {
+	return ct.pairs[[2]Shape{a, b}] || ct.pairs[[2]Shape{b, a}]
+}
+
+// Reset forgets the pairs of overlapping Shapes found so far, without reporting them as exiting; the next step reports
+// every overlapping pair as entering.
+func (ct *ContactTracker) Reset() {
+	ct.pairs = map[[2]Shape]bool{}
+	ct.order = nil
+}
@@ -0,0 +1,165 @@
+package resolv_test
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContactTracker(t *testing.T) {
+	space := NewSpace()
+	zone := NewRectangle(0, 0, 32, 32)
+	zone.AddTags("zone")
+	player := NewRectangle(-20, 8, 16, 16)
+	wall := NewRectangle(-20, 20, 64, 16)
+	space.Add(zone, player, wall)
+
+	tracker := NewContactTracker()
+	entered, stayed, exited := 0, 0, 0
+	tracker.OnEnter = func(a, b Shape) { entered++ }
+	tracker.OnStay = func(a, b Shape) { stayed++ }
+	tracker.OnExit = func(a, b Shape) { exited++ }
+
+	t.Run("Enter, stay, and exit", func(t *testing.T) {
+		// The wall overlaps both the zone and the player from the start.
+		assert.Equal(t, []ContactEvent{
+			{ContactEnter, zone, wall},
+			{ContactEnter, player, wall},
+		}, tracker.Step(space))
+
+		player.Move(10, 0)
+		assert.Equal(t, []ContactEvent{
+			{ContactEnter, zone, player},
+			{ContactStay, zone, wall},
+			{ContactStay, player, wall},
+		}, tracker.Step(space))
+		assert.True(t, tracker.IsTouching(player, zone))
+
+		player.Move(0, -40)
+		assert.Equal(t, []ContactEvent{
+			{ContactStay, zone, wall},
+			{ContactExit, zone, player},
+			{ContactExit, player, wall},
+		}, tracker.Step(space))
+		assert.False(t, tracker.IsTouching(player, zone))
+
+		assert.Equal(t, 3, entered)
+		assert.Equal(t, 3, stayed)
+		assert.Equal(t, 2, exited)
+	})
+
+	t.Run("Pairs keep their order", func(t *testing.T) {
+		tracker.Reset()
+		player.SetXY(8, 8)
+		tracker.Step(space)
+
+		// Reordering the Space doesn't change the order of the Shapes in the events.
+		space.Remove(zone)
+		space.Add(zone)
+		events := tracker.Step(space)
+		assert.Contains(t, events, ContactEvent{ContactStay, zone, player})
+
+		// Removed Shapes exit.
+		space.Remove(zone)
+		events = tracker.Step(space)
+		assert.Contains(t, events, ContactEvent{ContactExit, zone, player})
+		assert.Contains(t, events, ContactEvent{ContactExit, zone, wall})
+		space.Add(zone)
+	})
+
+	t.Run("Filtering by tags and layers", func(t *testing.T) {
+		tracker := NewContactTracker()
+		space.Clear()
+		space.Add(zone, player, wall)
+
+		// Only Shapes tagged as zones, and the Shapes they select with their masks, take part.
+		zone.SetMask(1 << 1)
+		player.SetLayer(1 << 1)
+		wall.SetLayer(1 << 2)
+		tracker.Filter = func(shape Shape) bool {
+			return shape.HasTags("zone") || shape == player
+		}
+		assert.Equal(t, []ContactEvent{{ContactEnter, zone, player}}, tracker.Step(space))
+
+		// The player and the wall keep the default mask, which selects every layer, so the zone's mask decides.
+		tracker.Filter = nil
+		tracker.Reset()
+		events := tracker.Step(space)
+		assert.Contains(t, events, ContactEvent{ContactEnter, zone, player})
+		assert.NotContains(t, events, ContactEvent{ContactEnter, zone, wall})
+	})
+}
+
+func TestContactTracker_StepIndexed(t *testing.T) {
+	for name, newIndexedSpace := range broadphases() {
+		t.Run(name, func(t *testing.T) {
+			space := NewSpace()
+			indexed := newIndexedSpace()
+			movers := []*Rectangle{}
+
+			for i := 0; i < 200; i++ {
+				rect := NewRectangle(rand.Float64()*400, rand.Float64()*400, 16, 16)
+				if i%4 == 0 {
+					rect.AddTags("mover")
+					movers = append(movers, rect)
+				}
+				space.Add(rect)
+				indexed.Add(rect)
+			}
+
+			tracker := NewContactTracker()
+			indexedTracker := NewContactTracker()
+			filter := func(shape Shape) bool { return shape.HasTags("mover") }
+
+			for frame := 0; frame < 20; frame++ {
+				for _, mover := range movers {
+					mover.Move(rand.Float64()*8-4, rand.Float64()*8-4)
+				}
+				indexed.Update(*space...)
+
+				if frame == 10 {
+					tracker.Filter = filter
+					indexedTracker.Filter = filter
+				}
+
+				assert.Equal(t, tracker.Step(space), indexedTracker.StepIndexed(indexed))
+			}
+		})
+	}
+}
+
+// BenchmarkContactTracker steps a level of 10,000 static tiles with a few Shapes moving around in it.
+func BenchmarkContactTracker(b *testing.B) {
+	space := NewSpace()
+	indexed := NewIndexedSpace()
+
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			tile := NewRectangle(float64(x*16), float64(y*16), 16, 16)
+			space.Add(tile)
+			indexed.Add(tile)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		mover := NewRectangle(rand.Float64()*1600, rand.Float64()*1600, 12, 12)
+		space.Add(mover)
+		indexed.Add(mover)
+	}
+
+	b.Run("Step", func(b *testing.B) {
+		tracker := NewContactTracker()
+		for i := 0; i < b.N; i++ {
+			tracker.Step(space)
+		}
+	})
+
+	b.Run("StepIndexed", func(b *testing.B) {
+		tracker := NewContactTracker()
+		for i := 0; i < b.N; i++ {
+			tracker.StepIndexed(indexed)
+		}
+	})
+}
@@ -0,0 +1,257 @@
+package resolv
+
+import (
+	"math"
+	"sort"
+)
+
+// Point represents a position (or an offset) in 2D space.
+type Point struct {
+	X, Y float64
+}
+
+func (p Point) add(other Point) Point {
+	return Point{p.X + other.X, p.Y + other.Y}
+}
+
+func (p Point) sub(other Point) Point {
+	return Point{p.X - other.X, p.Y - other.Y}
+}
+
+func (p Point) scale(s float64) Point {
+	return Point{p.X * s, p.Y * s}
+}
+
+func (p Point) dot(other Point) float64 {
+	return p.X*other.X + p.Y*other.Y
+}
+
+func (p Point) cross(other Point) float64 {
+	return p.X*other.Y - p.Y*other.X
+}
+
+func (p Point) length() float64 {
+	return math.Sqrt(p.dot(p))
+}
+
+func (p Point) normalized() Point {
+	ln := p.length()
+	if ln == 0 {
+		return p
+	}
+	return Point{p.X / ln, p.Y / ln}
+}
+
+// perp returns the Point rotated by 90 degrees.
+func (p Point) perp() Point {
+	return Point{p.Y, -p.X}
+}
+
+// hull is a convex set of points, optionally inflated by a radius. All of the built-in, non-compound Shapes can be
+// expressed as one (a Circle is a single point with a radius, a Line is two points, and so on), which allows collision
+// tests to be written once for every pair of Shapes. The points are always in world space.
+type hull struct {
+	points []Point
+	radius float64
+}
+
+// toHull returns the hull representing the Shape provided, and whether the Shape could be represented as one.
+func toHull(shape Shape) (hull, bool) {
+
+	switch s := shape.(type) {
+	case *Rectangle:
+		return hull{points: []Point{{s.X, s.Y}, {s.X + s.W, s.Y}, {s.X + s.W, s.Y + s.H}, {s.X, s.Y + s.H}}}, true
+	case *Circle:
+		return hull{points: []Point{{s.X, s.Y}}, radius: s.Radius}, true
+	case *Line:
+		return hull{points: []Point{{s.X, s.Y}, {s.X2, s.Y2}}}, true
+	case *ConvexPolygon:
+		return hull{points: s.GetVertices()}, true
+	}
+
+	return hull{}, false
+
+}
+
+// center returns the average of the hull's points.
+func (h hull) center() Point {
+	c := Point{}
+	for _, p := range h.points {
+		c = c.add(p)
+	}
+	return c.scale(1 / float64(len(h.points)))
+}
+
+// project returns the interval the hull covers when projected onto the axis provided.
+func (h hull) project(axis Point) (float64, float64) {
+	min := math.Inf(1)
+	max := math.Inf(-1)
+	for _, p := range h.points {
+		d := p.dot(axis)
+		min = math.Min(min, d)
+		max = math.Max(max, d)
+	}
+	return min - h.radius, max + h.radius
+}
+
+// closestPoint returns the point of the hull that is closest to the target Point.
+func (h hull) closestPoint(target Point) Point {
+	closest := h.points[0]
+	for _, p := range h.points[1:] {
+		if p.sub(target).length() < closest.sub(target).length() {
+			closest = p
+		}
+	}
+	return closest
+}
+
+// axes returns the axes that need to be checked to see if the hull is separated from the other hull. These are the
+// normals of the hull's edges; if the hull is a single segment, the segment's direction is needed as well, and if the
+// hull is a single point (a Circle, or a segment of no length), the direction towards the other hull's closest point is
+// used.
+func (h hull) axes(other hull) []Point {
+
+	axes := []Point{}
+
+	pointAxis := func(c Point) Point {
+		axis := other.closestPoint(c).sub(c).normalized()
+		if axis.X == 0 && axis.Y == 0 {
+			axis = Point{1, 0}
+		}
+		return axis
+	}
+
+	switch len(h.points) {
+	case 1:
+		axes = append(axes, pointAxis(h.points[0]))
+	case 2:
+		dir := h.points[1].sub(h.points[0]).normalized()
+		if dir.X != 0 || dir.Y != 0 {
+			axes = append(axes, dir, dir.perp())
+		} else {
+			axes = append(axes, pointAxis(h.points[0]))
+		}
+	default:
+		for i := range h.points {
+			edge := h.points[(i+1)%len(h.points)].sub(h.points[i])
+			if edge.X != 0 || edge.Y != 0 {
+				axes = append(axes, edge.perp().normalized())
+			}
+		}
+	}
+
+	return axes
+
+}
+
+// sat runs a Separating Axis Theorem test between the two hulls. If they overlap, it returns the unit normal along which hull
+// a should move to stop overlapping hull b, the distance it would have to move (the penetration depth), and true.
+// Hulls that are only touching aren't considered to be overlapping.
+func sat(a, b hull) (Point, float64, bool) {
+
+	normal := Point{}
+	depth := math.Inf(1)
+
+	for _, axes := range [][]Point{a.axes(b), b.axes(a)} {
+
+		for _, axis := range axes {
+
+			minA, maxA := a.project(axis)
+			minB, maxB := b.project(axis)
+
+			if maxA <= minB || maxB <= minA {
+				return Point{}, 0, false
+			}
+
+			// Pushing a backwards along the axis or forwards along it; whichever is shorter.
+			if back := maxA - minB; back < depth {
+				depth = back
+				normal = axis.scale(-1)
+			}
+			if forward := maxB - minA; forward < depth {
+				depth = forward
+				normal = axis
+			}
+
+		}
+
+	}
+
+	if math.IsInf(depth, 1) {
+		return Point{}, 0, false
+	}
+
+	return normal, depth, true
+
+}
+
+// touches returns whether the two hulls overlap or are touching, using the same Separating Axis Theorem test as sat(), but
+// without working out how they overlap. Hulls that don't give any axes to test along aren't touching.
+func touches(a, b hull) bool {
+
+	tested := false
+
+	for _, axes := range [][]Point{a.axes(b), b.axes(a)} {
+		for _, axis := range axes {
+			minA, maxA := a.project(axis)
+			minB, maxB := b.project(axis)
+			if maxA < minB || maxB < minA {
+				return false
+			}
+			tested = true
+		}
+	}
+
+	return tested
+
+}
+
+// convexHull returns the convex hull of the Points provided in counter-clockwise order (as seen with the Y axis pointing
+// upwards), without any collinear or duplicate points.
+func convexHull(points []Point) []Point {
+
+	sorted := append([]Point{}, points...)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X == sorted[j].X {
+			return sorted[i].Y < sorted[j].Y
+		}
+		return sorted[i].X < sorted[j].X
+	})
+
+	if len(sorted) < 3 {
+		if len(sorted) == 2 && sorted[0] == sorted[1] {
+			return sorted[:1]
+		}
+		return sorted
+	}
+
+	out := make([]Point, 0, len(sorted)*2)
+
+	// Andrew's monotone chain; the lower hull, and then the upper hull.
+	for _, p := range sorted {
+		for len(out) >= 2 && out[len(out)-1].sub(out[len(out)-2]).cross(p.sub(out[len(out)-2])) <= 0 {
+			out = out[:len(out)-1]
+		}
+		out = append(out, p)
+	}
+
+	lower := len(out) + 1
+
+	for i := len(sorted) - 2; i >= 0; i-- {
+		p := sorted[i]
+		for len(out) >= lower && out[len(out)-1].sub(out[len(out)-2]).cross(p.sub(out[len(out)-2])) <= 0 {
+			out = out[:len(out)-1]
+		}
+		out = append(out, p)
+	}
+
+	out = out[:len(out)-1]
+
+	if len(out) == 2 && out[0] == out[1] {
+		out = out[:1]
+	}
+
+	return out
+
+}
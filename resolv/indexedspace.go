@@ -0,0 +1,400 @@
+package resolv
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/SolarLune/resolv/resolv/aabb"
+)
+
+/*An IndexedSpace is a collection of Shapes, just like a Space, that additionally keeps every Shape registered in a
+broadphase (an aabb.Tree, unless another aabb.Broadphase, like an aabb.SpatialHash, is provided) by its bounding rectangle.
+Collision queries against an IndexedSpace first ask the broadphase for the Shapes whose bounding rectangles overlap the
+area of interest, and only test those, rather than testing every Shape. This makes it a good fit for large levels with
+many static Shapes, where a plain Space would cost O(n) for every moving object.
+
+The broadphase can't tell when a Shape moves, so Shapes that move after being added need to be passed to Update() to
+refresh their position in it. Static Shapes never need to be updated.*/
+type IndexedSpace struct {
+	shapes     Space
+	broadphase aabb.Broadphase
+	proxies    map[Shape]*shapeProxy
+	added      uint64
+}
+
+// shapeProxy is the object that's registered in the broadphase for each Shape within an IndexedSpace. It stores the bounding
+// box the Shape had when it was last inserted or updated, and when the Shape was added, relative to the other Shapes.
+type shapeProxy struct {
+	shape  Shape
+	bounds aabb.AABBData
+	order  uint64
+}
+
+// AABB returns the bounding box of the proxied Shape.
+func (p *shapeProxy) AABB() *aabb.AABBData {
+	return &p.bounds
+}
+
+func (p *shapeProxy) refresh() {
+	p.bounds = rectToAABB(p.shape.GetBoundingRect())
+}
+
+// rectToAABB returns the bounding box of the Rectangle, grown by Tolerance on every side (and by at least the smallest
+// step a float64 can take, for far off coordinates). The broadphases only count boxes that overlap by more than nothing
+// as overlapping, so this way they also find Shapes that are only touching, and Shapes with no width or height, like
+// horizontal and vertical Lines, which the collision tests count as colliding.
+func rectToAABB(r *Rectangle) aabb.AABBData {
+	down, up := math.Inf(-1), math.Inf(1)
+	return aabb.AABBData{
+		MinX: math.Nextafter(r.X-Tolerance, down),
+		MinY: math.Nextafter(r.Y-Tolerance, down),
+		MaxX: math.Nextafter(r.X+r.W+Tolerance, up),
+		MaxY: math.Nextafter(r.Y+r.H+Tolerance, up),
+	}
+}
+
+const (
+	// treeMargin is the Margin of the aabb.Tree created by NewIndexedSpace(), which suits Shapes measured in pixels.
+	treeMargin = 2
+	// treeDisplacementMultiplier is the DisplacementMultiplier of the aabb.Tree created by NewIndexedSpace() and
+	// NewIndexedSpaceWithMargin().
+	treeDisplacementMultiplier = 2
+)
+
+// NewIndexedSpace creates a new, empty IndexedSpace, using an aabb.Tree as its broadphase. The leaves of the tree are
+// grown by a margin of 2 on every side, and are stretched ahead of moving Shapes by twice their last movement, so that
+// Shapes that move by a few pixels at a time don't need to be reinserted into the tree on every Update(). The margin is
+// meant for Shapes measured in pixels; for Shapes measured in other units (like meters), use NewIndexedSpaceWithMargin().
+func NewIndexedSpace() *IndexedSpace {
+	return NewIndexedSpaceWithMargin(treeMargin)
+}
+
+// NewIndexedSpaceWithMargin creates a new, empty IndexedSpace like NewIndexedSpace() does, but with the leaves of the tree
+// grown by the margin provided instead. It should be about as far as Shapes usually move in a frame, in the units they're
+// measured in; a margin of 0 makes every Update() of a moving Shape reinsert it into the tree.
+func NewIndexedSpaceWithMargin(margin float64) *IndexedSpace {
+	tree := aabb.NewTree()
+	tree.Margin = margin
+	tree.DisplacementMultiplier = treeDisplacementMultiplier
+	return NewIndexedSpaceWithBroadphase(tree)
+}
+
+// NewIndexedSpaceWithBroadphase creates a new, empty IndexedSpace, using the broadphase provided to find the Shapes to
+// test. The broadphase should be empty, and shouldn't be used for anything else.
+func NewIndexedSpaceWithBroadphase(broadphase aabb.Broadphase) *IndexedSpace {
+	return &IndexedSpace{
+		shapes:     Space{},
+		broadphase: broadphase,
+		proxies:    map[Shape]*shapeProxy{},
+	}
+}
+
+// Add adds the designated Shapes to the IndexedSpace, registering them in the broadphase using their current bounding rectangles.
+// Shapes that are already in the IndexedSpace are skipped. If the broadphase fails to register a Shape, the Shape isn't
+// added (while the other Shapes still are), and the broadphase's error is returned.
+func (is *IndexedSpace) Add(shapes ...Shape) error {
+	var err error
+	for _, shape := range shapes {
+		if _, exists := is.proxies[shape]; exists {
+			continue
+		}
+		proxy := &shapeProxy{shape: shape, order: is.added}
+		is.added++
+		proxy.refresh()
+		if insertErr := is.broadphase.TryInsert(proxy); insertErr != nil {
+			err = insertErr
+			continue
+		}
+		is.proxies[shape] = proxy
+		is.shapes = append(is.shapes, shape)
+	}
+	return err
+}
+
+// Remove removes the designated Shapes from the IndexedSpace. If the broadphase fails to remove a Shape, the Shape is still
+// removed from the IndexedSpace, but the broadphase's error is returned.
+func (is *IndexedSpace) Remove(shapes ...Shape) error {
+	var err error
+	for _, shape := range shapes {
+		proxy, exists := is.proxies[shape]
+		if !exists {
+			continue
+		}
+		if removeErr := is.broadphase.TryRemove(proxy); removeErr != nil {
+			err = removeErr
+		}
+		delete(is.proxies, shape)
+		is.shapes.Remove(shape)
+	}
+	return err
+}
+
+// Update refreshes the position of the designated Shapes in the broadphase. It should be called after moving a Shape that
+// is in the IndexedSpace; otherwise, queries will keep using the Shape's old bounding rectangle to find it. If the
+// broadphase fails to update a Shape, the other Shapes are still updated, and the broadphase's error is returned.
+func (is *IndexedSpace) Update(shapes ...Shape) error {
+	var err error
+	for _, shape := range shapes {
+		proxy, exists := is.proxies[shape]
+		if !exists {
+			continue
+		}
+		proxy.refresh()
+		if _, updateErr := is.broadphase.TryUpdate(proxy); updateErr != nil {
+			err = updateErr
+		}
+	}
+	return err
+}
+
+// UpdateAll refreshes the positions of all Shapes in the broadphase, returning the broadphase's error if it fails to
+// update any of them.
+func (is *IndexedSpace) UpdateAll() error {
+	return is.Update(is.shapes...)
+}
+
+// Clear "resets" the IndexedSpace, removing all Shapes from it. If the broadphase fails to remove any of them, they're
+// still removed from the IndexedSpace, but the first of the broadphase's errors is returned.
+func (is *IndexedSpace) Clear() error {
+	var err error
+	for _, shape := range is.shapes {
+		if removeErr := is.broadphase.TryRemove(is.proxies[shape]); removeErr != nil && err == nil {
+			err = removeErr
+		}
+	}
+	is.shapes = Space{}
+	is.proxies = map[Shape]*shapeProxy{}
+	return err
+}
+
+// Contains returns true if the Shape provided exists within the IndexedSpace.
+func (is *IndexedSpace) Contains(shape Shape) bool {
+	_, exists := is.proxies[shape]
+	return exists
+}
+
+// Length returns the number of Shapes contained within the IndexedSpace.
+func (is *IndexedSpace) Length() int {
+	return len(is.shapes)
+}
+
+// Shapes returns a Space holding all of the Shapes within the IndexedSpace, in the order they were added. Adding Shapes to
+// or removing them from the returned Space doesn't alter the IndexedSpace.
+func (is *IndexedSpace) Shapes() *Space {
+	shapes := make(Space, len(is.shapes))
+	copy(shapes, is.shapes)
+	return &shapes
+}
+
+// Query returns a Space comprised of the Shapes whose bounding rectangles overlap the Rectangle provided. These are the
+// candidates that the other IndexedSpace functions run their collision tests against. They're in the order they were
+// added to the IndexedSpace, whatever order the broadphase finds them in, so that functions that depend on the order of
+// the Shapes, like Resolve(), give the same results as they would with a Space, whichever broadphase is used.
+func (is *IndexedSpace) Query(rect *Rectangle) *Space {
+
+	bounds := rectToAABB(rect)
+
+	proxies := []*shapeProxy{}
+
+	is.broadphase.QueryOverlapsFunc(&bounds, func(found aabb.AABB) bool {
+		proxies = append(proxies, found.(*shapeProxy))
+		return true
+	})
+
+	sort.Slice(proxies, func(i, j int) bool {
+		return proxies[i].order < proxies[j].order
+	})
+
+	candidates := make(Space, len(proxies))
+	for i, proxy := range proxies {
+		candidates[i] = proxy.shape
+	}
+
+	return &candidates
+
+}
+
+// candidatePairs calls visit for each pair of Shapes in the IndexedSpace whose bounding rectangles overlap, as found by the
+// broadphase. The pairs are sorted in the order of the Shapes in the IndexedSpace, the same way as if every pair of Shapes
+// was gone through, and the first Shape of each pair comes before the second.
+func (is *IndexedSpace) candidatePairs(visit func(a, b Shape)) {
+
+	pairs := is.broadphase.QueryAllPairs()
+	proxies := make([][2]*shapeProxy, len(pairs))
+
+	for i, pair := range pairs {
+		a, b := pair.A.(*shapeProxy), pair.B.(*shapeProxy)
+		if a.order > b.order {
+			a, b = b, a
+		}
+		proxies[i] = [2]*shapeProxy{a, b}
+	}
+
+	sort.Slice(proxies, func(i, j int) bool {
+		if proxies[i][0].order != proxies[j][0].order {
+			return proxies[i][0].order < proxies[j][0].order
+		}
+		return proxies[i][1].order < proxies[j][1].order
+	})
+
+	for _, pair := range proxies {
+		visit(pair[0].shape, pair[1].shape)
+	}
+
+}
+
+// IsColliding returns whether the provided Shape is colliding with something in this IndexedSpace.
+func (is *IndexedSpace) IsColliding(shape Shape) bool {
+	return is.Query(shape.GetBoundingRect()).IsColliding(shape)
+}
+
+// GetCollidingShapes returns a Space comprised of Shapes that collide with the checking Shape.
+func (is *IndexedSpace) GetCollidingShapes(shape Shape) *Space {
+	return is.Query(shape.GetBoundingRect()).GetCollidingShapes(shape)
+}
+
+// Resolve runs Space.Resolve() using the checking Shape, checking against the Shapes in the IndexedSpace that lie within
+// the area swept by the checking Shape as it moves by deltaX and deltaY. Like with a Space, the Collision returned is
+// with the first of those Shapes that the checking Shape would collide with, in the order they were added;
+// ResolveNearest() returns the one that it would collide with first instead.
+func (is *IndexedSpace) Resolve(checkingShape Shape, deltaX, deltaY float64) Collision {
+	return is.Query(sweptRect(checkingShape, deltaX, deltaY)).Resolve(checkingShape, deltaX, deltaY)
+}
+
+// ResolveAll runs Space.ResolveAll() using the checking Shape against the Shapes in the IndexedSpace that lie within the area
+// swept by the checking Shape, returning every Collision found sorted by Time.
+func (is *IndexedSpace) ResolveAll(checkingShape Shape, deltaX, deltaY float64) []Collision {
+	return is.Query(sweptRect(checkingShape, deltaX, deltaY)).ResolveAll(checkingShape, deltaX, deltaY)
+}
+
+// ResolveNearest runs Space.ResolveNearest() using the checking Shape against the Shapes in the IndexedSpace that lie
+// within the area swept by the checking Shape, returning the Collision that would happen first.
+func (is *IndexedSpace) ResolveNearest(checkingShape Shape, deltaX, deltaY float64) Collision {
+	return is.Query(sweptRect(checkingShape, deltaX, deltaY)).ResolveNearest(checkingShape, deltaX, deltaY)
+}
+
+// ResolveSlide runs Space.ResolveSlide() using the checking Shape against the Shapes in the IndexedSpace that lie within
+// reach of the movement. As sliding can change the direction of the movement, that's the area around the checking Shape
+// that's as far out as the length of the movement.
+func (is *IndexedSpace) ResolveSlide(checkingShape Shape, deltaX, deltaY float64) Collision {
+	reach := Distance(0, 0, deltaX, deltaY)
+	r := checkingShape.GetBoundingRect()
+	area := NewRectangle(r.X-reach, r.Y-reach, r.W+reach*2, r.H+reach*2)
+	return is.Query(area).ResolveSlide(checkingShape, deltaX, deltaY)
+}
+
+// Raycast casts a ray in the same way as Space.Raycast(), but walks the broadphase along the ray, only testing the Shapes
+// whose bounding rectangles it crosses, and skipping those that lie beyond the nearest hit found so far. Rays of unlimited
+// length are cut off once they're past every Shape, which takes a look at the bounds of every Shape to find out.
+func (is *IndexedSpace) Raycast(originX, originY, dirX, dirY, maxDist float64, filter func(Shape) bool) RaycastHit {
+
+	nearest := RaycastHit{}
+
+	is.raycast(originX, originY, dirX, dirY, maxDist, filter, func(hit RaycastHit) bool {
+		if !nearest.Hit() || hit.Distance < nearest.Distance {
+			nearest = hit
+		}
+		return true
+	})
+
+	return nearest
+
+}
+
+// RaycastAll casts a ray in the same way as Space.RaycastAll(), but walks the broadphase along the ray, only testing the
+// Shapes whose bounding rectangles it crosses.
+func (is *IndexedSpace) RaycastAll(originX, originY, dirX, dirY, maxDist float64, filter func(Shape) bool) []RaycastHit {
+
+	hits := []RaycastHit{}
+
+	is.raycast(originX, originY, dirX, dirY, maxDist, filter, func(hit RaycastHit) bool {
+		hits = append(hits, hit)
+		return false
+	})
+
+	sortRaycastHits(hits)
+
+	return hits
+
+}
+
+// raycast passes every hit along the ray to found, which returns whether the ray should be clipped to the hit.
+func (is *IndexedSpace) raycast(originX, originY, dirX, dirY, maxDist float64, filter func(Shape) bool, found func(RaycastHit) bool) {
+
+	dir := Point{dirX, dirY}.normalized()
+
+	if !(maxDist > 0) || (dir == Point{}) || len(is.shapes) == 0 {
+		return
+	}
+
+	origin := Point{originX, originY}
+
+	// The broadphase can't walk a ray of unlimited length, so it's cut off once past every Shape.
+	if math.IsInf(maxDist, 1) {
+		maxDist = rayReach(origin, is.bounds())
+	}
+
+	delta := dir.scale(maxDist)
+
+	is.broadphase.RayCast(origin.X, origin.Y, delta.X, delta.Y, 1, func(object aabb.AABB, maxFraction float64) float64 {
+
+		shape := object.(*shapeProxy).shape
+
+		if filter != nil && !filter(shape) {
+			return -1
+		}
+
+		hit, ok := raycastShape(origin, dir, maxDist, shape, filter)
+		if !ok || hit.Distance > maxFraction*maxDist {
+			return -1
+		}
+
+		if found(hit) {
+			return hit.Distance / maxDist
+		}
+		return maxFraction
+
+	})
+
+}
+
+func (is *IndexedSpace) String() string {
+	return fmt.Sprintf("IndexedSpace{%v}", &is.shapes)
+}
+
+// bounds returns a Rectangle that wholly contains every Shape in the IndexedSpace.
+func (is *IndexedSpace) bounds() *Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, proxy := range is.proxies {
+		minX = math.Min(minX, proxy.bounds.MinX)
+		minY = math.Min(minY, proxy.bounds.MinY)
+		maxX = math.Max(maxX, proxy.bounds.MaxX)
+		maxY = math.Max(maxY, proxy.bounds.MaxY)
+	}
+	return NewRectangle(minX, minY, maxX-minX, maxY-minY)
+}
+
+// sweptRect returns a Rectangle that wholly contains the Shape both at its current position and once moved by dx and dy.
+func sweptRect(shape Shape, dx, dy float64) *Rectangle {
+
+	r := shape.GetBoundingRect()
+
+	if dx < 0 {
+		r.X += dx
+		r.W -= dx
+	} else {
+		r.W += dx
+	}
+
+	if dy < 0 {
+		r.Y += dy
+		r.H -= dy
+	} else {
+		r.H += dy
+	}
+
+	return r
+
+}
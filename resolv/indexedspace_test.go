@@ -0,0 +1,247 @@
+package resolv_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv"
+	"github.com/SolarLune/resolv/resolv/aabb"
+	"github.com/stretchr/testify/assert"
+)
+
+// broadphases returns a constructor for an IndexedSpace using each of the broadphases available.
+func broadphases() map[string]func() *IndexedSpace {
+	return map[string]func() *IndexedSpace{
+		"Tree": NewIndexedSpace,
+		"Tree (meters)": func() *IndexedSpace {
+			return NewIndexedSpaceWithMargin(0.05)
+		},
+		"SpatialHash": func() *IndexedSpace {
+			return NewIndexedSpaceWithBroadphase(aabb.NewSpatialHash(32))
+		},
+		"SweepAndPrune": func() *IndexedSpace {
+			return NewIndexedSpaceWithBroadphase(aabb.NewSweepAndPrune())
+		},
+	}
+}
+
+func TestIndexedSpace_MatchesSpace(t *testing.T) {
+	for name, newIndexedSpace := range broadphases() {
+		t.Run(name, func(t *testing.T) {
+			space := NewSpace()
+			indexed := newIndexedSpace()
+
+			for i := 0; i < 1000; i++ {
+				rect := NewRectangle(rand.Float64()*1000, rand.Float64()*1000, 8, 8)
+				space.Add(rect)
+				indexed.Add(rect)
+			}
+
+			for i := 0; i < 100; i++ {
+				probe := NewRectangle(rand.Float64()*1000, rand.Float64()*1000, 16, 16)
+
+				assert.Equal(t, space.IsColliding(probe), indexed.IsColliding(probe))
+				assert.ElementsMatch(t, *space.GetCollidingShapes(probe), *indexed.GetCollidingShapes(probe))
+			}
+
+			indexed.Clear()
+			assert.False(t, indexed.IsColliding(NewRectangle(0, 0, 1000, 1000)))
+		})
+	}
+}
+
+func TestIndexedSpace_MatchesSpaceWhenTouching(t *testing.T) {
+
+	// Shapes that only touch, or that have no width or height, have bounding rectangles that only touch too.
+	newScene := func() []Shape {
+		return []Shape{
+			NewRectangle(0, 0, 16, 16),
+			NewLine(100, 0, 132, 0),
+			NewCircle(200, 8, 8),
+			NewConvexPolygon(300, 0, 0, 0, 16, 0, 16, 16, 0, 16),
+			NewLine(1e6, 1e6, 1e6, 1e6+16),
+		}
+	}
+
+	newProbes := func() []Shape {
+		return []Shape{
+			NewCircle(24, 8, 8),                                  // Touching the Rectangle's side.
+			NewLine(0, 0, 16, 0),                                 // Lying on the Rectangle's top edge.
+			NewLine(16, 4, 24, 4),                                // Ending on the Rectangle's side.
+			NewLine(120, 0, 150, 0),                              // Overlapping the Line, collinear with it.
+			NewLine(132, 0, 140, 0),                              // Continuing on from the end of the Line.
+			NewRectangle(208, 0, 16, 16),                         // Touching the Circle's side.
+			NewConvexPolygon(316, 0, 0, 0, 16, 0, 16, 16, 0, 16), // Touching the ConvexPolygon's side.
+			NewCircle(1e6-4, 1e6+8, 4),                           // Touching the Line, far from the origin.
+		}
+	}
+
+	for name, newIndexedSpace := range broadphases() {
+		t.Run(name, func(t *testing.T) {
+			space := NewSpace()
+			indexed := newIndexedSpace()
+			scene := newScene()
+			space.Add(scene...)
+			indexed.Add(scene...)
+
+			for _, probe := range newProbes() {
+				assert.Equal(t, space.IsColliding(probe), indexed.IsColliding(probe), "%v", probe)
+				assert.ElementsMatch(t, *space.GetCollidingShapes(probe), *indexed.GetCollidingShapes(probe), "%v", probe)
+			}
+		})
+	}
+
+}
+
+func TestIndexedSpace_Resolve(t *testing.T) {
+	indexed := NewIndexedSpace()
+	player := NewRectangle(0, 0, 16, 16)
+	wall := NewRectangle(64, 0, 16, 16)
+	indexed.Add(player, wall)
+
+	res := indexed.Resolve(player, 56, 0)
+	assert.True(t, res.Colliding())
+	assert.Equal(t, wall, res.ShapeB)
+
+	res = indexed.Resolve(player, 0, 56)
+	assert.False(t, res.Colliding())
+
+	// With several Shapes in the way, the Collision is with the first of them that was added, as with a Space, whichever
+	// broadphase is used.
+	for name, newIndexedSpace := range broadphases() {
+		space := NewSpace()
+		other := newIndexedSpace()
+		shapes := []Shape{player, NewRectangle(200, 0, 16, 16), NewRectangle(100, 0, 16, 16), NewRectangle(150, 0, 16, 16)}
+		space.Add(shapes...)
+		other.Add(shapes...)
+		assert.Equal(t, space.Resolve(player, 300, 0), other.Resolve(player, 300, 0), name)
+		assert.Equal(t, *space.GetCollidingShapes(NewRectangle(0, 0, 300, 16)), *other.GetCollidingShapes(NewRectangle(0, 0, 300, 16)), name)
+	}
+
+	// Sliding down a ramp leaves the area swept by the movement, so Shapes out to the side count too.
+	indexed = NewIndexedSpace()
+	ramp := NewLine(-100, 200, 100, 0)
+	player.X, player.Y = 34, 34
+	indexed.Add(player, ramp)
+
+	res = indexed.ResolveSlide(player, 0, 8)
+	assert.True(t, res.Colliding())
+	assert.Equal(t, ramp, res.ShapeB)
+	assert.InDelta(t, -4.0, res.ResolveX, 1e-9)
+	assert.InDelta(t, 4.0, res.ResolveY, 1e-9)
+}
+
+func TestIndexedSpace_Raycast(t *testing.T) {
+	for name, newIndexedSpace := range broadphases() {
+		t.Run(name, func(t *testing.T) {
+			space := NewSpace()
+			indexed := newIndexedSpace()
+
+			for i := 0; i < 200; i++ {
+				shape := NewRectangle(rand.Float64()*1000, rand.Float64()*1000, 16, 16)
+				space.Add(shape)
+				indexed.Add(shape)
+			}
+
+			for i := 0; i < 50; i++ {
+				x, y := rand.Float64()*1000, rand.Float64()*1000
+				dirX, dirY := rand.Float64()*2-1, rand.Float64()*2-1
+
+				// Shapes that are hit at the same distance (like overlapping Shapes containing the origin) may come in
+				// any order.
+				expected := space.Raycast(x, y, dirX, dirY, 500, nil)
+				hit := indexed.Raycast(x, y, dirX, dirY, 500, nil)
+				assert.Equal(t, expected.Hit(), hit.Hit())
+				assert.InDelta(t, expected.Distance, hit.Distance, 1e-9)
+
+				assert.ElementsMatch(t, space.RaycastAll(x, y, dirX, dirY, 500, nil), indexed.RaycastAll(x, y, dirX, dirY, 500, nil))
+			}
+		})
+	}
+}
+
+func TestIndexedSpace_Update(t *testing.T) {
+	indexed := NewIndexedSpace()
+	mover := NewRectangle(0, 0, 16, 16)
+	indexed.Add(mover)
+
+	probe := NewRectangle(200, 200, 4, 4)
+	assert.False(t, indexed.IsColliding(probe))
+
+	mover.SetXY(195, 195)
+	assert.False(t, indexed.IsColliding(probe), "moved Shapes are not found until they're updated")
+
+	indexed.Update(mover)
+	assert.True(t, indexed.IsColliding(probe))
+
+	indexed.Remove(mover)
+	assert.False(t, indexed.IsColliding(probe))
+	assert.Equal(t, 0, indexed.Length())
+}
+
+// failingBroadphase is a broadphase that fails to do anything with the objects that fail is set to return true for.
+type failingBroadphase struct {
+	aabb.Broadphase
+	fail func(object aabb.AABB) bool
+}
+
+var errBroadphase = errors.New("broadphase failure")
+
+func (b *failingBroadphase) TryInsert(object aabb.AABB) error {
+	if b.fail(object) {
+		return errBroadphase
+	}
+	return b.Broadphase.TryInsert(object)
+}
+
+func (b *failingBroadphase) TryRemove(object aabb.AABB) error {
+	if b.fail(object) {
+		return errBroadphase
+	}
+	return b.Broadphase.TryRemove(object)
+}
+
+func (b *failingBroadphase) TryUpdate(object aabb.AABB) (bool, error) {
+	if b.fail(object) {
+		return false, errBroadphase
+	}
+	return b.Broadphase.TryUpdate(object)
+}
+
+func TestIndexedSpace_Errors(t *testing.T) {
+	failing := true
+	broadphase := &failingBroadphase{Broadphase: aabb.NewTree()}
+	broadphase.fail = func(object aabb.AABB) bool {
+		return failing && object.AABB().MinX > 100
+	}
+
+	indexed := NewIndexedSpaceWithBroadphase(broadphase)
+	good := NewRectangle(0, 0, 16, 16)
+	bad := NewRectangle(200, 0, 16, 16)
+
+	assert.Equal(t, errBroadphase, indexed.Add(good, bad))
+	assert.True(t, indexed.Contains(good))
+	assert.False(t, indexed.Contains(bad), "Shapes that the broadphase fails to register aren't added")
+
+	failing = false
+	assert.NoError(t, indexed.Add(bad))
+	assert.NoError(t, indexed.UpdateAll())
+
+	failing = true
+	good.SetXY(300, 0)
+	assert.Equal(t, errBroadphase, indexed.UpdateAll())
+
+	assert.Equal(t, errBroadphase, indexed.Remove(bad))
+	assert.False(t, indexed.Contains(bad))
+	assert.Equal(t, 1, indexed.Length())
+
+	assert.NoError(t, indexed.Add(NewRectangle(0, 32, 16, 16)))
+	assert.Equal(t, errBroadphase, indexed.Clear())
+	assert.Equal(t, 0, indexed.Length())
+	assert.False(t, indexed.Contains(good))
+
+	failing = false
+	assert.NoError(t, indexed.Add(good))
+	assert.NoError(t, indexed.Clear())
+}
@@ -21,12 +21,24 @@ func NewLine(x, y, x2, y2 float64) *Line {
 	return l
 }
 
-// BUG(SolarLune): Line.IsColliding() and Line.GetIntersectionPoints() doesn't work with Circles.
-// BUG(SolarLune): Line.IsColliding() and Line.GetIntersectionPoints() fail if testing two lines that intersect along the exact same slope.
-
-// IsColliding returns if the Line is colliding with the other Shape. Currently, Circle-Line collision is missing.
+// IsColliding returns if the Line is colliding with the other Shape. A Line that lies wholly within a Rectangle or Circle
+// is colliding with it, even though it doesn't intersect its outline.
 func (l *Line) IsColliding(other Shape) bool {
 
+	if !isBuiltin(other) {
+		return collideCustom(l, other)
+	}
+
+	switch b := other.(type) {
+	case *Space:
+		return b.IsColliding(l)
+	case *ConvexPolygon:
+		return b.IsColliding(l)
+	case *Circle:
+		x, y := l.ClosestPoint(b.X, b.Y)
+		return Distance(x, y, b.X, b.Y) <= b.Radius
+	}
+
 	intersectionPoints := l.GetIntersectionPoints(other)
 
 	colliding := len(intersectionPoints) > 0
@@ -48,7 +60,8 @@ type IntersectionPoint struct {
 
 // GetIntersectionPoints returns the intersection points of a Line with another Shape as an array of IntersectionPoints.
 // The returned list of intersection points are always sorted in order of distance from the start of the casting Line to each intersection.
-// Currently, Circle-Line collision is missing.
+// A Line crosses the outline of a Circle at up to two points; if it just touches the Circle, there's a single point, and
+// if it lies wholly within the Circle, there are none.
 func (l *Line) GetIntersectionPoints(other Shape) []IntersectionPoint {
 
 	intersections := []IntersectionPoint{}
@@ -56,53 +69,61 @@ func (l *Line) GetIntersectionPoints(other Shape) []IntersectionPoint {
 	switch b := other.(type) {
 
 	case *Line:
+		for _, point := range l.segmentIntersections(b) {
+			intersections = append(intersections, IntersectionPoint{point.X, point.Y, other})
+		}
+	case *Rectangle:
+		sides := []*Line{
+			NewLine(b.X, b.Y, b.X, b.Y+b.H),
+			NewLine(b.X, b.Y+b.H, b.X+b.W, b.Y+b.H),
+			NewLine(b.X+b.W, b.Y+b.H, b.X+b.W, b.Y),
+			NewLine(b.X+b.W, b.Y, b.X, b.Y),
+		}
+		for _, side := range sides {
+			for _, point := range l.segmentIntersections(side) {
+				intersections = appendUnique(intersections, IntersectionPoint{point.X, point.Y, other})
+			}
+		}
+	case *Space:
+		for _, shape := range *b {
+			intersections = append(intersections, l.GetIntersectionPoints(shape)...)
+		}
+	case *ConvexPolygon:
+		for _, side := range b.GetLines() {
+			for _, point := range l.segmentIntersections(side) {
+				intersections = appendUnique(intersections, IntersectionPoint{point.X, point.Y, other})
+			}
+		}
+	case *Circle:
 
-		det := (l.X2-l.X)*(b.Y2-b.Y) - (b.X2-b.X)*(l.Y2-l.Y)
+		// Solving for the points along the Line (start + t * delta, with t between 0 and 1) that lie at exactly the
+		// Circle's radius from its center.
+		dx, dy := l.GetDelta()
+		fx := l.X - b.X
+		fy := l.Y - b.Y
 
-		if det != 0 {
+		a := dx*dx + dy*dy
+		c := fx*fx + fy*fy - b.Radius*b.Radius
+		half := fx*dx + fy*dy
 
-			// MAGIC MATH; the extra + 1 here makes it so that corner cases (literally aiming the line through the corners of the
-			// hollow square in world5) works!
+		disc := half*half - a*c
 
-			lambda := (((l.Y - b.Y) * (b.X2 - b.X)) - ((l.X - b.X) * (b.Y2 - b.Y)) + 1) / det
+		if a == 0 || disc < 0 {
+			break
+		}
 
-			gamma := (((l.Y - b.Y) * (l.X2 - l.X)) - ((l.X - b.X) * (l.Y2 - l.Y)) + 1) / det
+		root := math.Sqrt(disc)
 
-			if (0 < lambda && lambda < 1) && (0 < gamma && gamma < 1) {
-				dx, dy := l.GetDelta()
-				intersections = append(intersections, IntersectionPoint{l.X + lambda*dx, l.Y + lambda*dy, other})
+		for _, t := range []float64{(-half - root) / a, (-half + root) / a} {
+			if t >= 0 && t <= 1 {
+				intersections = append(intersections, IntersectionPoint{l.X + t*dx, l.Y + t*dy, other})
+			}
+			if disc == 0 {
+				break
 			}
-
-		}
-	case *Rectangle:
-		side := NewLine(b.X, b.Y, b.X, b.Y+b.H)
-		intersections = append(intersections, l.GetIntersectionPoints(side)...)
-
-		side.Y = b.Y + b.H
-		side.X2 = b.X + b.W
-		side.Y2 = b.Y + b.H
-		intersections = append(intersections, l.GetIntersectionPoints(side)...)
-
-		side.X = b.X + b.W
-		side.Y2 = b.Y
-		intersections = append(intersections, l.GetIntersectionPoints(side)...)
-
-		side.Y = b.Y
-		side.X2 = b.X
-		side.Y2 = b.Y
-		intersections = append(intersections, l.GetIntersectionPoints(side)...)
-	case *Space:
-		for _, shape := range *b {
-			intersections = append(intersections, l.GetIntersectionPoints(shape)...)
 		}
-	case *Circle:
-		// 	TO-DO: Add this later, because this is kinda hard and would necessitate some complex vector math that, for whatever
-		//  reason, is not even readily available in a Golang library as far as I can tell???
-		break
 	}
 
-	// fmt.Println("WARNING! Object ", other, " isn't a valid shape for collision testing against Line ", l, "!")
-
 	sort.Slice(intersections, func(i, j int) bool {
 		return Distance(l.X, l.Y, intersections[i].X, intersections[i].Y) < Distance(l.X, l.Y, intersections[j].X, intersections[j].Y)
 	})
@@ -111,6 +132,93 @@ func (l *Line) GetIntersectionPoints(other Shape) []IntersectionPoint {
 
 }
 
+// segmentIntersections returns the points at which the Line intersects the other Line. Lines that merely touch (including
+// at their end points) intersect. Collinear Lines that overlap intersect along the whole overlap, which is reported as
+// the two end points of the overlap (or a single point if the overlap is just one point).
+func (l *Line) segmentIntersections(other *Line) []Point {
+
+	start := Point{l.X, l.Y}
+	r := Point{l.X2 - l.X, l.Y2 - l.Y}
+	s := Point{other.X2 - other.X, other.Y2 - other.Y}
+	offset := Point{other.X - l.X, other.Y - l.Y}
+
+	denom := r.cross(s)
+
+	if denom != 0 {
+
+		t := offset.cross(s) / denom
+		u := offset.cross(r) / denom
+
+		if t >= 0 && t <= 1 && u >= 0 && u <= 1 {
+			return []Point{start.add(r.scale(t))}
+		}
+
+		return nil
+
+	}
+
+	// The Lines are parallel; they can only intersect if they're on the same line, as well.
+	if offset.cross(r) != 0 || offset.cross(s) != 0 {
+		return nil
+	}
+
+	lengthSquared := r.dot(r)
+
+	if lengthSquared == 0 {
+		// This Line is just a point, which lies on the other Line if it's within its bounds.
+		if (s.dot(s) == 0 && offset == Point{}) || (s.dot(s) != 0 && offset.dot(s) <= 0 && offset.add(s).dot(s) >= 0) {
+			return []Point{start}
+		}
+		return nil
+	}
+
+	// Projecting the other Line onto this one, and then finding the overlap. The end points of the overlap are always end
+	// points of one of the Lines, so they're returned as-is rather than being recalculated from the projection.
+	end := Point{l.X2, l.Y2}
+	otherStart := Point{other.X, other.Y}
+	otherEnd := Point{other.X2, other.Y2}
+
+	t0 := offset.dot(r) / lengthSquared
+	t1 := otherEnd.sub(start).dot(r) / lengthSquared
+
+	if t0 > t1 {
+		t0, t1 = t1, t0
+		otherStart, otherEnd = otherEnd, otherStart
+	}
+
+	if t0 > 1 || t1 < 0 {
+		return nil
+	}
+
+	first, last := start, end
+
+	if t0 > 0 {
+		first = otherStart
+	}
+
+	if t1 < 1 {
+		last = otherEnd
+	}
+
+	if first == last {
+		return []Point{first}
+	}
+
+	return []Point{first, last}
+
+}
+
+// appendUnique appends the IntersectionPoint to the slice provided, unless it's already in it (as happens when a Line
+// passes through the corner of a Shape, and so intersects two of its edges at the same point).
+func appendUnique(points []IntersectionPoint, point IntersectionPoint) []IntersectionPoint {
+	for _, p := range points {
+		if p == point {
+			return points
+		}
+	}
+	return append(points, point)
+}
+
 // WouldBeColliding returns if the Line would be colliding if it were moved by the designated delta X and Y values.
 func (l *Line) WouldBeColliding(other Shape, dx, dy float64) bool {
 	l.X += dx
@@ -153,6 +261,23 @@ func (l *Line) Center() (float64, float64) {
 
 }
 
+// ClosestPoint returns the point on the Line that is closest to the X and Y values provided.
+func (l *Line) ClosestPoint(x, y float64) (float64, float64) {
+
+	dx, dy := l.GetDelta()
+	lengthSquared := dx*dx + dy*dy
+
+	if lengthSquared == 0 {
+		return l.X, l.Y
+	}
+
+	t := ((x-l.X)*dx + (y-l.Y)*dy) / lengthSquared
+	t = math.Max(0, math.Min(1, t))
+
+	return l.X + t*dx, l.Y + t*dy
+
+}
+
 // GetLength returns the length of the Line.
 func (l *Line) GetLength() float64 {
 	return Distance(l.X, l.Y, l.X2, l.Y2)
@@ -191,6 +316,11 @@ func (l *Line) GetBoundingRectangle() *Rectangle {
 
 }
 
+// GetBoundingRect returns the same Rectangle as GetBoundingRectangle(); it allows the Line to fulfill the Shape interface.
+func (l *Line) GetBoundingRect() *Rectangle {
+	return l.GetBoundingRectangle()
+}
+
 // GetBoundingCircle returns a circle centered on the Line's central point that would fully contain the Line.
 func (l *Line) GetBoundingCircle() *Circle {
 
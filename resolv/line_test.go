@@ -0,0 +1,162 @@
+package resolv_test
+
+import (
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLine_Circle(t *testing.T) {
+	circle := NewCircle(0, 0, 10)
+
+	tests := []struct {
+		name      string
+		line      *Line
+		colliding bool
+		points    []IntersectionPoint
+	}{
+		{
+			name:      "Crossing through",
+			line:      NewLine(-20, 0, 20, 0),
+			colliding: true,
+			points:    []IntersectionPoint{{X: -10, Y: 0, Shape: circle}, {X: 10, Y: 0, Shape: circle}},
+		},
+		{
+			name:      "Crossing through, backwards",
+			line:      NewLine(20, 0, -20, 0),
+			colliding: true,
+			points:    []IntersectionPoint{{X: 10, Y: 0, Shape: circle}, {X: -10, Y: 0, Shape: circle}},
+		},
+		{
+			name:      "Ending inside",
+			line:      NewLine(0, -20, 0, 5),
+			colliding: true,
+			points:    []IntersectionPoint{{X: 0, Y: -10, Shape: circle}},
+		},
+		{
+			name:      "Tangent",
+			line:      NewLine(-20, 10, 20, 10),
+			colliding: true,
+			points:    []IntersectionPoint{{X: 0, Y: 10, Shape: circle}},
+		},
+		{
+			name:      "Fully contained",
+			line:      NewLine(-5, -5, 5, 5),
+			colliding: true,
+			points:    []IntersectionPoint{},
+		},
+		{
+			name:      "Passing by",
+			line:      NewLine(-20, 11, 20, 11),
+			colliding: false,
+			points:    []IntersectionPoint{},
+		},
+		{
+			name:      "Stopping short",
+			line:      NewLine(-30, 0, -15, 0),
+			colliding: false,
+			points:    []IntersectionPoint{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.points, tt.line.GetIntersectionPoints(circle))
+			assert.Equal(t, tt.colliding, tt.line.IsColliding(circle))
+			assert.Equal(t, tt.colliding, circle.IsColliding(tt.line))
+		})
+	}
+}
+
+func TestLine_Circle_Space(t *testing.T) {
+	space := NewSpace()
+	enemy := NewCircle(100, 0, 8)
+	space.Add(enemy)
+
+	bullet := NewLine(80, 0, 96, 0)
+	assert.True(t, space.IsColliding(bullet))
+	assert.Len(t, bullet.GetIntersectionPoints(space), 1)
+
+	res := space.Resolve(bullet, 20, 0)
+	assert.True(t, res.Colliding())
+	assert.InDelta(t, -4, res.ResolveX, 1e-9)
+}
+
+func TestLine_Space(t *testing.T) {
+	const (
+		walls = 1 << iota
+		enemies
+	)
+
+	wall := NewRectangle(40, -10, 10, 20)
+	wall.SetLayer(walls)
+	enemy := NewCircle(100, 0, 20)
+	enemy.SetLayer(enemies)
+	space := NewSpace()
+	space.Add(wall, enemy)
+
+	bullet := NewLine(35, 0, 45, 0)
+	bullet.SetMask(enemies)
+	assert.False(t, bullet.IsColliding(space), "the wall isn't on a layer selected by the Line's mask")
+	assert.Equal(t, space.IsColliding(bullet), bullet.IsColliding(space))
+
+	// A Line wholly inside a Shape in the Space doesn't cross its outline, but is still colliding with it.
+	bullet = NewLine(95, 0, 105, 0)
+	assert.True(t, bullet.IsColliding(enemy))
+	assert.True(t, bullet.IsColliding(space))
+}
+
+func TestLine_ClosestPoint(t *testing.T) {
+	line := NewLine(0, 0, 10, 0)
+
+	x, y := line.ClosestPoint(5, 5)
+	assert.Equal(t, 5.0, x)
+	assert.Equal(t, 0.0, y)
+
+	x, y = line.ClosestPoint(-5, 5)
+	assert.Equal(t, 0.0, x)
+	assert.Equal(t, 0.0, y)
+}
+
+func TestLine_Line(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   *Line
+		points []Point
+	}{
+		{"Crossing", NewLine(0, 0, 10, 10), NewLine(0, 10, 10, 0), []Point{{5, 5}}},
+		{"Crossing at fractional coordinates", NewLine(0, 0, 0.5, 0.5), NewLine(0, 0.5, 0.5, 0), []Point{{0.25, 0.25}}},
+		{"Touching end points", NewLine(0, 0, 10, 0), NewLine(10, 0, 10, 10), []Point{{10, 0}}},
+		{"End point touching the middle", NewLine(0, 0, 10, 0), NewLine(5, 0, 5, 10), []Point{{5, 0}}},
+		{"Stopping short", NewLine(0, 0, 10, 0), NewLine(5, 1, 5, 10), []Point{}},
+		{"Parallel", NewLine(0, 0, 10, 0), NewLine(0, 1, 10, 1), []Point{}},
+		{"Collinear and overlapping", NewLine(0, 0, 10, 0), NewLine(5, 0, 15, 0), []Point{{5, 0}, {10, 0}}},
+		{"Collinear and contained", NewLine(0, 0, 10, 10), NewLine(8, 8, 2, 2), []Point{{2, 2}, {8, 8}}},
+		{"Collinear and touching", NewLine(0, 0, 10, 0), NewLine(10, 0, 20, 0), []Point{{10, 0}}},
+		{"Collinear and apart", NewLine(0, 0, 10, 0), NewLine(11, 0, 20, 0), []Point{}},
+		{"A point on a Line", NewLine(3, 3, 3, 3), NewLine(0, 0, 10, 10), []Point{{3, 3}}},
+		{"A point beside a Line", NewLine(11, 11, 11, 11), NewLine(0, 0, 10, 10), []Point{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := []Point{}
+			for _, p := range tt.a.GetIntersectionPoints(tt.b) {
+				assert.Equal(t, tt.b, p.Shape)
+				points = append(points, Point{p.X, p.Y})
+			}
+			assert.Equal(t, tt.points, points)
+			assert.Equal(t, len(tt.points) > 0, tt.a.IsColliding(tt.b))
+			assert.Equal(t, len(tt.points) > 0, tt.b.IsColliding(tt.a))
+		})
+	}
+}
+
+func TestLine_Rectangle_Corner(t *testing.T) {
+	rect := NewRectangle(10, 10, 10, 10)
+	line := NewLine(0, 0, 30, 30)
+
+	points := line.GetIntersectionPoints(rect)
+	assert.Equal(t, []IntersectionPoint{{X: 10, Y: 10, Shape: rect}, {X: 20, Y: 20, Shape: rect}}, points)
+}
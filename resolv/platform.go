@@ -0,0 +1,110 @@
+package resolv
+
+/*Platform moves a Shape through a Space kinematically, like a moving platform or an elevator; it follows the movement it's
+given regardless of what's in its way, carries the Shapes that are standing on it along with it, and pushes the Shapes it
+runs into out of its way, so that they don't end up embedded in it.
+
+A Shape is standing on the Platform if it's resting on top of it (within RideDistance, relative to UpX and UpY) when Move()
+is called. Riders are moved by the same amount as the Platform, but stop at the other Shapes in the Space, so a rider
+carried into a wall stays behind. Shapes overlapping the Platform after it has moved are pushed out by the shortest way
+out (see Penetration()), again stopping at the other Shapes in the Space. One-way Platforms (see BasicShape.OneWayX) carry
+their riders, but don't push anything, as other Shapes can pass through them anyway.
+
+Only the Shapes in the Space on the layers selected by the Platform Shape's mask (see BasicShape.Mask) that pass the Filter
+(if one is set) are carried or pushed; as a mask of 0 selects every Shape, either of them should be used to keep the
+level's walls and floors from being shoved around.*/
+type Platform struct {
+	Shape Shape
+	Space *Space
+	// UpX and UpY are the unit vector pointing "up", from the Platform towards the Shapes standing on it.
+	UpX, UpY float64
+	// RideDistance is how far above the Platform a Shape can be and still count as standing on it.
+	RideDistance float64
+	// Filter, if set, decides which Shapes can be carried or pushed; Shapes that it returns false for are left alone.
+	Filter func(Shape) bool
+
+	riders []Shape
+}
+
+// NewPlatform returns a Platform that moves the Shape provided through the Space provided, with up pointing up the screen,
+// and a RideDistance of 1.
+func NewPlatform(shape Shape, space *Space) *Platform {
+	return &Platform{
+		Shape:        shape,
+		Space:        space,
+		UpX:          0,
+		UpY:          -1,
+		RideDistance: 1,
+	}
+}
+
+// Riders returns the Shapes that were standing on the Platform, and so were carried along, during the last Move().
+func (p *Platform) Riders() []Shape {
+	return p.riders
+}
+
+// Move moves the Platform's Shape by dx and dy, carrying its riders along and pushing the Shapes it runs into out of the
+// way.
+func (p *Platform) Move(dx, dy float64) {
+
+	candidates := p.Space.Filter(func(shape Shape) bool {
+		return shape != p.Shape && canCollide(p.Shape, shape) && (p.Filter == nil || p.Filter(shape))
+	})
+
+	p.riders = nil
+	for _, shape := range *candidates {
+		if p.isRiding(shape) {
+			p.riders = append(p.riders, shape)
+		}
+	}
+
+	// Riders are moved before the Platform is, so that they aren't blocked by it; the Platform itself is left out of
+	// their movement entirely.
+	for _, rider := range p.riders {
+		p.push(rider, Point{dx, dy})
+	}
+
+	p.Shape.Move(dx, dy)
+
+	if _, oneWay := isOneWay(p.Shape); oneWay {
+		return
+	}
+
+	for _, shape := range *candidates {
+		if res := Penetration(shape, p.Shape); res.Colliding() {
+			p.push(shape, Point{res.ResolveX, res.ResolveY})
+		}
+	}
+
+}
+
+// isRiding returns whether the Shape is resting on top of the Platform. Shapes that are only touching it (like those left
+// there by Resolve()) count, but Shapes that are overlapping it don't.
+func (p *Platform) isRiding(shape Shape) bool {
+
+	up := Point{p.UpX, p.UpY}
+
+	if res := Penetration(shape, p.Shape); res.Colliding() {
+		return false
+	}
+
+	res := Resolve(shape, p.Shape, -up.X*p.RideDistance, -up.Y*p.RideDistance)
+	return res.Colliding() && (Point{res.NormalX, res.NormalY}).dot(up) > epsilon
+
+}
+
+// push moves the Shape by the delta provided, stopping at the first Shape in the Space (other than the Platform) that it
+// runs into.
+func (p *Platform) push(shape Shape, delta Point) {
+
+	res := p.Space.Filter(func(other Shape) bool {
+		return other != p.Shape
+	}).ResolveNearest(shape, delta.X, delta.Y)
+
+	if res.Colliding() {
+		delta = Point{res.ResolveX, res.ResolveY}
+	}
+
+	shape.Move(delta.X, delta.Y)
+
+}
@@ -0,0 +1,113 @@
+package resolv_test
+
+import (
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlatform(t *testing.T) {
+
+	const (
+		solid = 1 << iota
+		movable
+	)
+
+	newWorld := func(shapes ...Shape) (*Rectangle, *Rectangle, *Platform) {
+		space := NewSpace()
+		platform := NewRectangle(0, 100, 64, 16)
+		platform.SetMask(movable)
+		box := NewRectangle(16, 84, 16, 16)
+		box.SetLayer(movable)
+		space.Add(platform, box)
+		for _, shape := range shapes {
+			shape.SetLayer(solid)
+		}
+		space.Add(shapes...)
+		return platform, box, NewPlatform(platform, space)
+	}
+
+	t.Run("Carrying riders", func(t *testing.T) {
+		platform, box, p := newWorld()
+
+		p.Move(0, -10)
+		assert.Equal(t, 90.0, platform.Y)
+		assert.Equal(t, 74.0, box.Y)
+		assert.Equal(t, []Shape{box}, p.Riders())
+
+		p.Move(5, 0)
+		assert.Equal(t, 21.0, box.X)
+
+		p.Move(0, 10)
+		assert.Equal(t, 84.0, box.Y)
+		assert.Equal(t, []Shape{box}, p.Riders())
+
+		// Shapes beside or below the platform aren't riding it.
+		box.X = 69
+		p.Move(0, -10)
+		assert.Equal(t, 84.0, box.Y)
+		assert.Empty(t, p.Riders())
+	})
+
+	t.Run("Circle riders", func(t *testing.T) {
+		platform, _, p := newWorld()
+		ball := NewCircle(48, 80, 8)
+		ball.SetLayer(movable)
+		p.Space.Add(ball)
+
+		// Landing leaves the ball exactly touching the platform.
+		res := p.Space.Resolve(ball, 0, 20)
+		assert.True(t, res.Colliding())
+		assert.Equal(t, platform, res.ShapeB)
+		ball.Move(res.ResolveX, res.ResolveY)
+		assert.True(t, ball.IsColliding(platform))
+
+		p.Move(5, 0)
+		assert.Equal(t, 53.0, ball.X)
+		assert.Contains(t, p.Riders(), ball)
+	})
+
+	t.Run("Riders stop at walls", func(t *testing.T) {
+		ceiling := NewRectangle(0, 60, 64, 20)
+		platform, box, p := newWorld(ceiling)
+
+		p.Move(0, -10)
+		assert.Equal(t, 90.0, platform.Y)
+		assert.Equal(t, 80.0, box.Y, "the rider is crushed rather than pushed into the ceiling")
+		assert.Equal(t, 60.0, ceiling.Y, "Shapes not selected by the platform's mask aren't moved")
+	})
+
+	t.Run("Pushing", func(t *testing.T) {
+		platform, box, p := newWorld()
+		box.X, box.Y = 66, 100
+
+		p.Move(10, 0)
+		assert.Equal(t, 10.0, platform.X)
+		assert.Equal(t, 74.0, box.X)
+		assert.Empty(t, p.Riders())
+	})
+
+	t.Run("One-way platforms", func(t *testing.T) {
+		platform, box, p := newWorld()
+		platform.SetOneWay(0, -1)
+
+		p.Move(0, -10)
+		assert.Equal(t, 74.0, box.Y)
+
+		// Shapes within a one-way platform are passing through it, and aren't pushed out.
+		box.X, box.Y = 16, 95
+		p.Move(0, -5)
+		assert.Equal(t, 95.0, box.Y)
+		assert.Empty(t, p.Riders())
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		_, box, p := newWorld()
+		p.Filter = func(shape Shape) bool { return shape != box }
+
+		p.Move(0, -10)
+		assert.Equal(t, 84.0, box.Y)
+	})
+
+}
@@ -0,0 +1,150 @@
+package resolv
+
+import "math"
+
+// ConvexPolygon represents a solid, convex polygon. Its Points are relative to its position, so moving the ConvexPolygon
+// moves all of its Points along with it. Collision testing against other Shapes is done using the Separating Axis Theorem,
+// which means that, unlike a Space of Lines, a ConvexPolygon also collides with Shapes that are wholly contained within it.
+type ConvexPolygon struct {
+	BasicShape
+	Points []Point
+}
+
+// NewConvexPolygon returns a pointer to a new ConvexPolygon at the position provided. The points argument is a list of
+// X and Y pairs (so x1, y1, x2, y2, and so on), relative to the position of the ConvexPolygon. If the points don't describe
+// a convex polygon, their convex hull is used instead; any trailing, unpaired value is ignored.
+func NewConvexPolygon(x, y float64, points ...float64) *ConvexPolygon {
+
+	p := &ConvexPolygon{}
+	p.X = x
+	p.Y = y
+
+	for i := 0; i+1 < len(points); i += 2 {
+		p.Points = append(p.Points, Point{points[i], points[i+1]})
+	}
+
+	p.Points = convexHull(p.Points)
+
+	return p
+
+}
+
+// GetVertices returns the Points of the ConvexPolygon in world space (so offset by the position of the ConvexPolygon).
+func (p *ConvexPolygon) GetVertices() []Point {
+	vertices := make([]Point, len(p.Points))
+	for i, point := range p.Points {
+		vertices[i] = Point{p.X + point.X, p.Y + point.Y}
+	}
+	return vertices
+}
+
+// IsColliding returns whether the ConvexPolygon is colliding with the specified other Shape or not, including the other
+// Shape being wholly contained within the ConvexPolygon. Touching counts the same way as it does for a Rectangle, so that
+// a ConvexPolygon can stand in for one; a ConvexPolygon only touching a Rectangle or another ConvexPolygon isn't colliding
+// with it, but one touching a Circle or a Line is.
+func (p *ConvexPolygon) IsColliding(other Shape) bool {
+
+	if len(p.Points) == 0 {
+		return false
+	}
+
+	if space, ok := other.(*Space); ok {
+		return space.IsColliding(p)
+	}
+
+	hullB, ok := toHull(other)
+	if !ok {
+		return collideCustom(p, other)
+	}
+	hullA, _ := toHull(p)
+
+	switch other.(type) {
+	case *Rectangle, *ConvexPolygon:
+		_, _, colliding := sat(hullA, hullB)
+		return colliding
+	default:
+		return touches(hullA, hullB)
+	}
+
+}
+
+// WouldBeColliding returns whether the ConvexPolygon would be colliding with the other Shape if it were to move in the
+// specified direction.
+func (p *ConvexPolygon) WouldBeColliding(other Shape, dx, dy float64) bool {
+	p.X += dx
+	p.Y += dy
+	isColliding := p.IsColliding(other)
+	p.X -= dx
+	p.Y -= dy
+	return isColliding
+}
+
+// Center returns the center point of the ConvexPolygon (the average of its vertices).
+func (p *ConvexPolygon) Center() (float64, float64) {
+
+	if len(p.Points) == 0 {
+		return p.X, p.Y
+	}
+
+	h, _ := toHull(p)
+	c := h.center()
+	return c.X, c.Y
+
+}
+
+// GetBoundingRect returns a Rectangle that wholly contains the ConvexPolygon.
+func (p *ConvexPolygon) GetBoundingRect() *Rectangle {
+
+	if len(p.Points) == 0 {
+		return NewRectangle(p.X, p.Y, 0, 0)
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, v := range p.GetVertices() {
+		minX = math.Min(minX, v.X)
+		minY = math.Min(minY, v.Y)
+		maxX = math.Max(maxX, v.X)
+		maxY = math.Max(maxY, v.Y)
+	}
+
+	return NewRectangle(minX, minY, maxX-minX, maxY-minY)
+
+}
+
+// GetBoundingCircle returns a circle centered on the ConvexPolygon's center point that wholly contains the ConvexPolygon.
+func (p *ConvexPolygon) GetBoundingCircle() *Circle {
+
+	x, y := p.Center()
+
+	radius := 0.0
+	for _, v := range p.GetVertices() {
+		radius = math.Max(radius, Distance(x, y, v.X, v.Y))
+	}
+
+	return NewCircle(x, y, radius)
+
+}
+
+// GetLines returns the edges of the ConvexPolygon as Lines, in world space.
+func (p *ConvexPolygon) GetLines() []*Line {
+
+	vertices := p.GetVertices()
+	lines := make([]*Line, 0, len(vertices))
+
+	if len(vertices) < 2 {
+		return lines
+	}
+
+	for i, v := range vertices {
+		next := vertices[(i+1)%len(vertices)]
+		lines = append(lines, NewLine(v.X, v.Y, next.X, next.Y))
+		if len(vertices) == 2 {
+			break
+		}
+	}
+
+	return lines
+
+}
@@ -0,0 +1,127 @@
+package resolv_test
+
+import (
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvexPolygon_IsColliding(t *testing.T) {
+	// A diamond centered on 32, 32.
+	diamond := NewConvexPolygon(32, 32, 0, -16, 16, 0, 0, 16, -16, 0)
+
+	tests := []struct {
+		name  string
+		other Shape
+		want  bool
+	}{
+		{"Rectangle overlapping a point", NewRectangle(44, 28, 16, 8), true},
+		{"Rectangle in the empty corner", NewRectangle(44, 44, 8, 8), false},
+		{"Rectangle wholly contained", NewRectangle(30, 30, 4, 4), true},
+		{"Rectangle wholly containing", NewRectangle(0, 0, 64, 64), true},
+		{"Circle overlapping an edge", NewCircle(44, 44, 6), true},
+		{"Circle near an edge", NewCircle(46, 46, 4), false},
+		{"Circle wholly contained", NewCircle(32, 32, 2), true},
+		{"Line crossing", NewLine(0, 32, 64, 32), true},
+		{"Line wholly contained", NewLine(30, 32, 34, 32), true},
+		{"Line outside", NewLine(48, 0, 64, 16), false},
+		{"ConvexPolygon overlapping", NewConvexPolygon(40, 32, 0, -4, 16, 0, 0, 4), true},
+		{"ConvexPolygon separated", NewConvexPolygon(50, 50, 0, 0, 16, 0, 0, 16), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, diamond.IsColliding(tt.other))
+			assert.Equal(t, tt.want, tt.other.IsColliding(diamond))
+		})
+	}
+}
+
+func TestConvexPolygon_StandsInForRectangle(t *testing.T) {
+	rect := NewRectangle(0, 0, 16, 16)
+	square := NewConvexPolygon(0, 0, 0, 0, 16, 0, 16, 16, 0, 16)
+
+	tests := []struct {
+		name  string
+		other Shape
+		want  bool
+	}{
+		{"Circle touching a side", NewCircle(24, 8, 8), true},
+		{"Circle touching a corner", NewCircle(19, 20, 5), true},
+		{"Circle apart", NewCircle(25, 8, 8), false},
+		{"Line ending on a side", NewLine(16, 4, 24, 4), true},
+		{"Line lying on the top edge", NewLine(-8, 0, 24, 0), true},
+		{"Line apart", NewLine(17, 0, 17, 16), false},
+		{"Rectangle touching a side", NewRectangle(16, 0, 16, 16), false},
+		{"Rectangle overlapping", NewRectangle(15, 0, 16, 16), true},
+		{"ConvexPolygon touching a side", NewConvexPolygon(16, 0, 0, 0, 16, 0, 16, 16, 0, 16), false},
+		{"ConvexPolygon overlapping", NewConvexPolygon(15, 0, 0, 0, 16, 0, 16, 16, 0, 16), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, rect.IsColliding(test.other))
+			assert.Equal(t, test.want, square.IsColliding(test.other))
+			assert.Equal(t, test.other.IsColliding(rect), test.other.IsColliding(square))
+		})
+	}
+}
+
+func TestConvexPolygon_Degenerate(t *testing.T) {
+	// Polygons whose points are all the same, or all in a line, collide like a point and a segment would.
+	point := NewConvexPolygon(10, 10, 0, 0, 0, 0, 0, 0)
+	segment := NewConvexPolygon(0, 10, 0, 0, 10, 0, 20, 0)
+
+	tests := []struct {
+		name    string
+		polygon *ConvexPolygon
+		other   Shape
+		want    bool
+	}{
+		{"Point within a Circle", point, NewCircle(12, 10, 4), true},
+		{"Point beside a Circle", point, NewCircle(16, 10, 4), false},
+		{"Point on a Line", point, NewLine(0, 10, 20, 10), true},
+		{"Point next to a Line", point, NewLine(0, 12, 20, 12), false},
+		{"Point at the same place as a Line of no length", point, NewLine(10, 10, 10, 10), true},
+		{"Point apart from a Line of no length", point, NewLine(11, 11, 11, 11), false},
+		{"Segment crossing a Line", segment, NewLine(5, 0, 5, 20), true},
+		{"Segment above a Circle", segment, NewCircle(10, 14, 3), false},
+		{"Segment touching a Circle", segment, NewCircle(10, 14, 4), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.polygon.IsColliding(test.other))
+		})
+	}
+}
+
+func TestConvexPolygon_Space(t *testing.T) {
+	space := NewSpace()
+	tri := NewConvexPolygon(0, 0, 0, 0, 16, 0, 0, 16)
+	space.Add(NewRectangle(100, 100, 16, 16), tri)
+
+	probe := NewCircle(4, 4, 1)
+	assert.True(t, space.IsColliding(probe))
+	assert.Equal(t, Space{tri}, *space.GetCollidingShapes(probe))
+
+	assert.True(t, tri.WouldBeColliding(space, 95, 95))
+	assert.False(t, tri.WouldBeColliding(space, 90, 90))
+}
+
+func TestConvexPolygon_Helpers(t *testing.T) {
+	// The points are given clockwise and include a point inside of the hull, which is discarded.
+	square := NewConvexPolygon(10, 10, 0, 0, 0, 8, 8, 8, 8, 0, 4, 4)
+	assert.Len(t, square.Points, 4)
+
+	x, y := square.Center()
+	assert.Equal(t, 14.0, x)
+	assert.Equal(t, 14.0, y)
+
+	assert.Equal(t, NewRectangle(10, 10, 8, 8), square.GetBoundingRect())
+	assert.Len(t, square.GetLines(), 4)
+
+	square.Move(5, 5)
+	assert.Equal(t, NewRectangle(15, 15, 8, 8), square.GetBoundingRect())
+}
@@ -0,0 +1,140 @@
+package resolv
+
+import (
+	"math"
+	"sort"
+)
+
+// RaycastHit describes a Shape that was hit by a ray.
+// Shape is the Shape that was hit; if the ray was cast against a Space that contains other Spaces, it's the Shape within
+// them that was hit. It's nil if nothing was hit.
+// X and Y are the point at which the ray hit the Shape.
+// NormalX and NormalY are the unit normal of the surface of the Shape that was hit, pointing back towards the ray's origin.
+// Distance is the distance from the ray's origin to the point at which it hit the Shape. Rays starting inside of a Shape
+// hit it at their origin, with a Distance of 0 and a normal pointing against the ray's direction.
+type RaycastHit struct {
+	Shape            Shape
+	X, Y             float64
+	NormalX, NormalY float64
+	Distance         float64
+}
+
+// Hit returns whether the RaycastHit is valid because the ray actually hit a Shape.
+func (r *RaycastHit) Hit() bool {
+	return r.Shape != nil
+}
+
+// Raycast casts a ray from the origin provided along the direction given by dirX and dirY (which doesn't need to be
+// normalized) for up to maxDist, and returns the nearest Shape in the Space that it hits. A maxDist of math.Inf(1) casts a
+// ray of unlimited length, while a maxDist that's 0 or less (or NaN) doesn't hit anything. If filter is not nil, only
+// Shapes that it returns true for are tested; this goes for the Shapes within Spaces in the Space as well.
+func (sp *Space) Raycast(originX, originY, dirX, dirY, maxDist float64, filter func(Shape) bool) RaycastHit {
+
+	nearest := RaycastHit{}
+
+	sp.raycast(originX, originY, dirX, dirY, maxDist, filter, func(hit RaycastHit) {
+		if !nearest.Hit() || hit.Distance < nearest.Distance {
+			nearest = hit
+		}
+	})
+
+	return nearest
+
+}
+
+// RaycastAll casts a ray in the same way as Raycast(), but returns every Shape in the Space that it hits, sorted by
+// distance from the origin of the ray.
+func (sp *Space) RaycastAll(originX, originY, dirX, dirY, maxDist float64, filter func(Shape) bool) []RaycastHit {
+
+	hits := []RaycastHit{}
+
+	sp.raycast(originX, originY, dirX, dirY, maxDist, filter, func(hit RaycastHit) {
+		hits = append(hits, hit)
+	})
+
+	sortRaycastHits(hits)
+
+	return hits
+
+}
+
+func (sp *Space) raycast(originX, originY, dirX, dirY, maxDist float64, filter func(Shape) bool, found func(RaycastHit)) {
+
+	dir := Point{dirX, dirY}.normalized()
+
+	if !(maxDist > 0) || (dir == Point{}) {
+		return
+	}
+
+	for _, shape := range *sp {
+		if filter != nil && !filter(shape) {
+			continue
+		}
+		if hit, ok := raycastShape(Point{originX, originY}, dir, maxDist, shape, filter); ok {
+			found(hit)
+		}
+	}
+
+}
+
+// raycastShape casts a ray against a single Shape (testing all of the Shapes within it that pass the filter, if it's a
+// Space), returning the nearest hit. Custom Shapes that can't be represented as a hull are never hit. A ray of unlimited
+// length is cast only as far as it needs to go to pass the Shape.
+func raycastShape(origin, dir Point, maxDist float64, shape Shape, filter func(Shape) bool) (RaycastHit, bool) {
+
+	if space, ok := shape.(*Space); ok {
+		hit := space.Raycast(origin.X, origin.Y, dir.X, dir.Y, maxDist, filter)
+		return hit, hit.Hit()
+	}
+
+	target, ok := toHull(shape)
+	if !ok || len(target.points) == 0 {
+		return RaycastHit{}, false
+	}
+
+	if math.IsInf(maxDist, 1) {
+		maxDist = rayReach(origin, shape.GetBoundingRect())
+	}
+
+	// Casting a ray is the same as sweeping a single point along it.
+	sweep, ok := sweepHulls(hull{points: []Point{origin}}, target, dir.scale(maxDist))
+
+	if !ok || sweep.exit < 0 || sweep.enter > 1 || (sweep.enter == sweep.exit && sweep.enter < 0) {
+		return RaycastHit{}, false
+	}
+
+	t := math.Max(sweep.enter, 0)
+	normal := sweep.normal
+
+	if sweep.enter < 0 {
+		normal = dir.scale(-1)
+	}
+
+	point := origin.add(dir.scale(t * maxDist))
+
+	return RaycastHit{
+		Shape:    shape,
+		X:        point.X,
+		Y:        point.Y,
+		NormalX:  normal.X,
+		NormalY:  normal.Y,
+		Distance: t * maxDist,
+	}, true
+
+}
+
+// rayReach returns how far a ray from the origin needs to go to get past all of the Rectangle, whichever way it points,
+// so that a ray of unlimited length can be cast as a ray of that length instead.
+func rayReach(origin Point, r *Rectangle) float64 {
+	reach := 0.0
+	for _, corner := range []Point{{r.X, r.Y}, {r.X + r.W, r.Y}, {r.X, r.Y + r.H}, {r.X + r.W, r.Y + r.H}} {
+		reach = math.Max(reach, corner.sub(origin).length())
+	}
+	return reach + 1
+}
+
+func sortRaycastHits(hits []RaycastHit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Distance < hits[j].Distance
+	})
+}
@@ -0,0 +1,120 @@
+package resolv_test
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpace_Raycast(t *testing.T) {
+	space := NewSpace()
+	wall := NewRectangle(50, -10, 10, 20)
+	enemy := NewCircle(30, 0, 5)
+	pane := NewLine(40, -10, 40, 10)
+	behind := NewRectangle(-50, -10, 10, 20)
+	space.Add(wall, enemy, pane, behind)
+
+	t.Run("Nearest hit", func(t *testing.T) {
+		hit := space.Raycast(0, 0, 1, 0, 100, nil)
+		assert.True(t, hit.Hit())
+		assert.Equal(t, enemy, hit.Shape)
+		assert.InDelta(t, 25, hit.X, 1e-9)
+		assert.InDelta(t, 0, hit.Y, 1e-9)
+		assert.InDelta(t, -1, hit.NormalX, 1e-9)
+		assert.InDelta(t, 25, hit.Distance, 1e-9)
+	})
+
+	t.Run("Filtered", func(t *testing.T) {
+		hit := space.Raycast(0, 0, 2, 0, 100, func(s Shape) bool { return s != enemy })
+		assert.Equal(t, pane, hit.Shape)
+		assert.InDelta(t, 40, hit.Distance, 1e-9)
+		assert.InDelta(t, -1, hit.NormalX, 1e-9)
+	})
+
+	t.Run("Out of range", func(t *testing.T) {
+		hit := space.Raycast(0, 0, 1, 0, 20, nil)
+		assert.False(t, hit.Hit())
+	})
+
+	t.Run("All hits", func(t *testing.T) {
+		hits := space.RaycastAll(0, 0, 1, 0, 100, nil)
+		assert.Len(t, hits, 3)
+		assert.Equal(t, enemy, hits[0].Shape)
+		assert.Equal(t, pane, hits[1].Shape)
+		assert.Equal(t, wall, hits[2].Shape)
+		assert.InDelta(t, 50, hits[2].Distance, 1e-9)
+	})
+
+	t.Run("Starting inside a Shape", func(t *testing.T) {
+		hit := space.Raycast(55, 0, 0, 1, 100, nil)
+		assert.Equal(t, wall, hit.Shape)
+		assert.Equal(t, 0.0, hit.Distance)
+		assert.Equal(t, -1.0, hit.NormalY)
+	})
+
+	t.Run("Diagonal", func(t *testing.T) {
+		hit := space.Raycast(40, 20, 1, -1, 100, nil)
+		assert.Equal(t, wall, hit.Shape)
+		assert.InDelta(t, 50, hit.X, 1e-9)
+		assert.InDelta(t, 10, hit.Y, 1e-9)
+	})
+
+	t.Run("IndexedSpace", func(t *testing.T) {
+		indexed := NewIndexedSpace()
+		indexed.Add(*space...)
+		assert.Equal(t, space.Raycast(0, 0, 1, 0, 100, nil), indexed.Raycast(0, 0, 1, 0, 100, nil))
+		assert.Equal(t, space.RaycastAll(0, 0, -1, 0, 100, nil), indexed.RaycastAll(0, 0, -1, 0, 100, nil))
+	})
+
+	t.Run("Unlimited length", func(t *testing.T) {
+		far := NewRectangle(1e7, -10, 10, 20)
+		unlimited := NewSpace()
+		unlimited.Add(*space...)
+		unlimited.Add(far)
+
+		hit := unlimited.Raycast(0, 0, 1, 0, math.Inf(1), func(s Shape) bool { return s == far })
+		assert.Equal(t, far, hit.Shape)
+		assert.InDelta(t, 1e7, hit.X, 1e-6)
+		assert.InDelta(t, 1e7, hit.Distance, 1e-6)
+
+		hits := unlimited.RaycastAll(0, 0, 1, 0, math.Inf(1), nil)
+		assert.Equal(t, []Shape{enemy, pane, wall, far}, []Shape{hits[0].Shape, hits[1].Shape, hits[2].Shape, hits[3].Shape})
+
+		assert.Nil(t, unlimited.Raycast(0, 0, 1, 0, math.NaN(), nil).Shape)
+
+		for name, newIndexedSpace := range broadphases() {
+			indexed := newIndexedSpace()
+			indexed.Add(*unlimited...)
+			// The rays are cut off at different lengths, so the hits can differ by rounding errors.
+			indexedHits := indexed.RaycastAll(0, 0, 1, 0, math.Inf(1), nil)
+			assert.Len(t, indexedHits, len(hits), name)
+			for i := range indexedHits {
+				assert.Equal(t, hits[i].Shape, indexedHits[i].Shape, name)
+				assert.InDelta(t, hits[i].Distance, indexedHits[i].Distance, 1e-6, name)
+			}
+			assert.Equal(t, enemy, indexed.Raycast(0, 0, 1, 0, math.Inf(1), nil).Shape, name)
+			assert.Nil(t, indexed.Raycast(0, 0, 1, 0, math.NaN(), nil).Shape, name)
+		}
+	})
+
+	t.Run("Filtering within Spaces", func(t *testing.T) {
+		shield := NewRectangle(0, 90, 10, 20)
+		shield.SetLayer(1)
+		core := NewCircle(20, 100, 5)
+		core.SetLayer(2)
+		compound := NewSpace()
+		compound.Add(shield, core)
+
+		level := NewSpace()
+		level.Add(compound)
+		indexed := NewIndexedSpace()
+		indexed.Add(compound)
+
+		hit := level.Raycast(-10, 100, 1, 0, 100, LayerFilter(2))
+		assert.Equal(t, core, hit.Shape)
+		assert.InDelta(t, 25, hit.Distance, 1e-9)
+		assert.Equal(t, hit, indexed.Raycast(-10, 100, 1, 0, 100, LayerFilter(2)))
+	})
+}
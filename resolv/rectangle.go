@@ -22,6 +22,9 @@ func (r *Rectangle) IsColliding(other Shape) bool {
 	case *Rectangle:
 		return r.X > b.X-r.W && r.Y > b.Y-r.H && r.X < b.X+b.W && r.Y < b.Y+b.H
 	default:
+		if !isBuiltin(b) {
+			return collideCustom(r, b)
+		}
 		return b.IsColliding(r)
 	}
 
@@ -48,6 +51,11 @@ func (r *Rectangle) Center() (float64, float64) {
 
 }
 
+// GetBoundingRect returns a copy of the Rectangle, which is its own bounding rectangle.
+func (r *Rectangle) GetBoundingRect() *Rectangle {
+	return NewRectangle(r.X, r.Y, r.W, r.H)
+}
+
 // GetBoundingCircle returns a circle that wholly contains the Rectangle.
 func (r *Rectangle) GetBoundingCircle() *Circle {
 
@@ -15,14 +15,35 @@ type Shape interface {
 	GetXY() (float64, float64)
 	SetXY(float64, float64)
 	Move(float64, float64)
+	GetBoundingRect() *Rectangle
+	GetLayer() uint64
+	SetLayer(uint64)
+	GetMask() uint64
+	SetMask(uint64)
+	GetOneWay() (float64, float64)
+	SetOneWay(float64, float64)
 }
 
 // BasicShape isn't to be used directly; it just has some basic functions and data, common to all structs that embed it, like
 // position and tags. It is embedded in other Shapes.
+//
+// Layer and Mask are bitsets used to filter collisions without having to filter Spaces by tags. Layer is the set of
+// layers that the Shape is on, and Mask is the set of layers that the Shape checks for collisions against; when checking a
+// Shape against a Space, only the Shapes in the Space whose Layer shares at least one bit with the checking Shape's Mask
+// are tested. A Mask of 0 (the default) checks against all Shapes, regardless of their Layer.
+//
+// OneWayX and OneWayY, if not both 0, make the Shape a one-way Shape (like a platform that can be jumped through from
+// below); they're the direction pointing out of the side of the Shape that blocks movement (so 0, -1 for a platform that
+// can be landed on from above). Resolving a movement against a one-way Shape only stops the moving Shape if it comes into
+// contact with that side while moving against the direction, and wasn't already overlapping the one-way Shape; otherwise,
+// it passes through. Testing for overlaps with IsColliding() isn't affected.
 type BasicShape struct {
-	X, Y float64
-	tags []string
-	Data interface{}
+	X, Y             float64
+	tags             []string
+	Data             interface{}
+	Layer            uint64
+	Mask             uint64
+	OneWayX, OneWayY float64
 }
 
 // GetTags returns a reference to the the string array representing the tags on the BasicShape.
@@ -108,3 +129,55 @@ func (b *BasicShape) Move(x, y float64) {
 	b.X += x
 	b.Y += y
 }
+
+// GetLayer returns the layer bitset of the Shape.
+func (b *BasicShape) GetLayer() uint64 {
+	return b.Layer
+}
+
+// SetLayer sets the layer bitset of the Shape.
+func (b *BasicShape) SetLayer(layer uint64) {
+	b.Layer = layer
+}
+
+// GetMask returns the mask bitset of the Shape.
+func (b *BasicShape) GetMask() uint64 {
+	return b.Mask
+}
+
+// SetMask sets the mask bitset of the Shape.
+func (b *BasicShape) SetMask(mask uint64) {
+	b.Mask = mask
+}
+
+// GetOneWay returns the direction of the blocking side of the Shape, if it's a one-way Shape, or 0, 0 otherwise.
+func (b *BasicShape) GetOneWay() (float64, float64) {
+	return b.OneWayX, b.OneWayY
+}
+
+// SetOneWay sets the direction of the blocking side of the Shape, making it a one-way Shape; setting it to 0, 0 makes the
+// Shape block movement from all sides again.
+func (b *BasicShape) SetOneWay(x, y float64) {
+	b.OneWayX = x
+	b.OneWayY = y
+}
+
+// isOneWay returns whether the Shape is a one-way Shape, and the direction of its blocking side.
+func isOneWay(shape Shape) (Point, bool) {
+	x, y := shape.GetOneWay()
+	return Point{x, y}, x != 0 || y != 0
+}
+
+// canCollide returns whether the other Shape is on a layer selected by the checking Shape's mask.
+func canCollide(checking, other Shape) bool {
+	mask := checking.GetMask()
+	return mask == 0 || mask&other.GetLayer() != 0
+}
+
+// LayerFilter returns a filter function, suitable for Space.Raycast(), that only accepts Shapes that are on a layer
+// selected by the mask provided. A mask of 0 accepts all Shapes.
+func LayerFilter(mask uint64) func(Shape) bool {
+	return func(shape Shape) bool {
+		return mask == 0 || mask&shape.GetLayer() != 0
+	}
+}
@@ -1,6 +1,10 @@
 package resolv
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"sort"
+)
 
 /*A Space represents a collection that holds Shapes for collision detection in the same common space. A Space is arbitrarily large -
 you can use one Space for a single level, room, or area in your game, or split it up if it makes more sense for your game design.
@@ -16,14 +20,18 @@ func NewSpace() *Space {
 	return sp
 }
 
-// Add adds the designated Shapes to the Space. You cannot add the Space to itself.
-func (sp *Space) Add(shapes ...Shape) {
+// Add adds the designated Shapes to the Space. You cannot add the Space to itself; if you attempt to, the Space is skipped
+// (while the other Shapes are still added), and ErrSpaceAddsItself is returned.
+func (sp *Space) Add(shapes ...Shape) error {
+	var err error
 	for _, shape := range shapes {
 		if shape == sp {
-			panic(fmt.Sprintf("ERROR! Space %s cannot add itself!", shape))
+			err = ErrSpaceAddsItself
+			continue
 		}
 		*sp = append(*sp, shape)
 	}
+	return err
 }
 
 // Remove removes the designated Shapes from the Space.
@@ -52,12 +60,13 @@ func (sp *Space) Clear() {
 	*sp = make(Space, 0)
 }
 
-// IsColliding returns whether the provided Shape is colliding with something in this Space.
+// IsColliding returns whether the provided Shape is colliding with something in this Space. Only Shapes on the layers
+// selected by the provided Shape's mask are tested (see BasicShape.Mask).
 func (sp *Space) IsColliding(shape Shape) bool {
 
 	for _, other := range *sp {
 
-		if other != shape {
+		if other != shape && canCollide(shape, other) {
 
 			if shape.IsColliding(other) {
 				return true
@@ -71,13 +80,14 @@ func (sp *Space) IsColliding(shape Shape) bool {
 
 }
 
-// GetCollidingShapes returns a Space comprised of Shapes that collide with the checking Shape.
+// GetCollidingShapes returns a Space comprised of Shapes that collide with the checking Shape, and that are on the layers
+// selected by the checking Shape's mask.
 func (sp *Space) GetCollidingShapes(shape Shape) *Space {
 
 	newSpace := NewSpace()
 
 	for _, other := range *sp {
-		if other != shape {
+		if other != shape && canCollide(shape, other) {
 			if shape.IsColliding(other) {
 				newSpace.Add(other)
 			}
@@ -88,17 +98,17 @@ func (sp *Space) GetCollidingShapes(shape Shape) *Space {
 
 }
 
-// Resolve runs Resolve() using the checking Shape, checking against all other Shapes in the Space. The first Collision
-// that returns true is the Collision that gets returned.
+// Resolve runs Resolve() using the checking Shape, checking against all other Shapes in the Space that are on the layers
+// selected by the checking Shape's mask. The first Collision that returns true is the Collision that gets returned.
 func (sp *Space) Resolve(checkingShape Shape, deltaX, deltaY float64) Collision {
 
 	res := Collision{}
 
 	for _, other := range *sp {
 
-		if other != checkingShape && checkingShape.WouldBeColliding(other, deltaX, deltaY) {
-			res = Resolve(checkingShape, other, deltaX, deltaY)
-			if res.Colliding() {
+		if other != checkingShape && canCollide(checkingShape, other) {
+			if c := Resolve(checkingShape, other, deltaX, deltaY); c.Colliding() {
+				res = c
 				break
 			}
 		}
@@ -109,6 +119,58 @@ func (sp *Space) Resolve(checkingShape Shape, deltaX, deltaY float64) Collision
 
 }
 
+// ResolveAll runs Resolve() using the checking Shape against all other Shapes in the Space (that are on the layers
+// selected by the checking Shape's mask), returning every Collision found along the movement. The Collisions are sorted
+// by their Time, so the first one is the Shape that the checking Shape would come into contact with first; ties are
+// broken by distance, so the order doesn't depend on the order of the Shapes in the Space.
+func (sp *Space) ResolveAll(checkingShape Shape, deltaX, deltaY float64) []Collision {
+
+	collisions := []Collision{}
+
+	for _, other := range *sp {
+		if other != checkingShape && canCollide(checkingShape, other) {
+			if c := Resolve(checkingShape, other, deltaX, deltaY); c.Colliding() {
+				collisions = append(collisions, c)
+			}
+		}
+	}
+
+	sort.SliceStable(collisions, func(i, j int) bool {
+		return collisions[i].before(collisions[j])
+	})
+
+	return collisions
+
+}
+
+// ResolveNearest runs Resolve() using the checking Shape against all other Shapes in the Space (that are on the layers
+// selected by the checking Shape's mask), returning the Collision with the Shape that the checking Shape would come
+// into contact with first. Unlike Resolve(), the result doesn't depend on the order of the Shapes in the Space.
+func (sp *Space) ResolveNearest(checkingShape Shape, deltaX, deltaY float64) Collision {
+
+	res := Collision{}
+
+	for _, other := range *sp {
+		if other != checkingShape && canCollide(checkingShape, other) {
+			if c := Resolve(checkingShape, other, deltaX, deltaY); c.Colliding() && (!res.Colliding() || c.before(res)) {
+				res = c
+			}
+		}
+	}
+
+	return res
+
+}
+
+// ResolveSlide runs ResolveSlide() using the checking Shape against all other Shapes in the Space (that are on the layers
+// selected by the checking Shape's mask), sliding along each Shape it runs into in turn. Like ResolveNearest(), ShapeB of
+// the returned Collision is the Shape within the Space that was last slid along, rather than the Space itself.
+func (sp *Space) ResolveSlide(checkingShape Shape, deltaX, deltaY float64) Collision {
+	return resolveSlide(checkingShape, func(dx, dy float64) Collision {
+		return sp.ResolveNearest(checkingShape, dx, dy)
+	}, deltaX, deltaY)
+}
+
 // Filter filters out a Space, returning a new Space comprised of Shapes that return true for the boolean function you provide.
 // This can be used to focus on a set of object for collision testing or resolution, or lower the number of Shapes to test
 // by filtering some out beforehand.
@@ -231,6 +293,53 @@ func (sp *Space) HasTags(tags ...string) bool {
 
 }
 
+// GetLayer returns the combined layers of all Shapes within the Space.
+func (sp *Space) GetLayer() uint64 {
+	layer := uint64(0)
+	for _, shape := range *sp {
+		layer |= shape.GetLayer()
+	}
+	return layer
+}
+
+// SetLayer sets the layer bitset of all Shapes within the Space.
+func (sp *Space) SetLayer(layer uint64) {
+	for _, shape := range *sp {
+		shape.SetLayer(layer)
+	}
+}
+
+// GetMask returns the mask of the first Shape within the Space. If there are no Shapes within the Space, it returns 0.
+func (sp *Space) GetMask() uint64 {
+	if len(*sp) > 0 {
+		return (*sp)[0].GetMask()
+	}
+	return 0
+}
+
+// SetMask sets the mask bitset of all Shapes within the Space.
+func (sp *Space) SetMask(mask uint64) {
+	for _, shape := range *sp {
+		shape.SetMask(mask)
+	}
+}
+
+// GetOneWay returns the one-way direction of the first Shape within the Space. If there are no Shapes within the Space,
+// it returns 0, 0.
+func (sp *Space) GetOneWay() (float64, float64) {
+	if len(*sp) > 0 {
+		return (*sp)[0].GetOneWay()
+	}
+	return 0, 0
+}
+
+// SetOneWay sets the one-way direction of all Shapes within the Space.
+func (sp *Space) SetOneWay(x, y float64) {
+	for _, shape := range *sp {
+		shape.SetOneWay(x, y)
+	}
+}
+
 // GetData returns the pointer to the object contained in the Data field of the first Shape within the Space. If there aren't
 // any Shapes within the Space, it returns nil.
 func (sp *Space) GetData() interface{} {
@@ -288,6 +397,30 @@ func (sp *Space) Move(dx, dy float64) {
 	}
 }
 
+// GetBoundingRect returns a Rectangle that wholly contains all Shapes within the Space. If there aren't any Shapes within
+// the Space, it returns an empty Rectangle at 0, 0.
+func (sp *Space) GetBoundingRect() *Rectangle {
+
+	if len(*sp) == 0 {
+		return NewRectangle(0, 0, 0, 0)
+	}
+
+	bounds := (*sp)[0].GetBoundingRect()
+	minX, minY := bounds.X, bounds.Y
+	maxX, maxY := bounds.X+bounds.W, bounds.Y+bounds.H
+
+	for _, shape := range (*sp)[1:] {
+		r := shape.GetBoundingRect()
+		minX = math.Min(minX, r.X)
+		minY = math.Min(minY, r.Y)
+		maxX = math.Max(maxX, r.X+r.W)
+		maxY = math.Max(maxY, r.Y+r.H)
+	}
+
+	return NewRectangle(minX, minY, maxX-minX, maxY-minY)
+
+}
+
 // Length returns the length of the Space (number of Shapes contained within the Space). This is a convenience function, standing in for len(*space).
 func (sp *Space) Length() int {
 	return len(*sp)
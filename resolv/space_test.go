@@ -0,0 +1,98 @@
+package resolv_test
+
+import (
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpace_ResolveAll(t *testing.T) {
+	box := NewRectangle(0, 0, 16, 16)
+	far := NewRectangle(80, 0, 16, 16)
+	near := NewRectangle(40, 0, 16, 16)
+	middle := NewCircle(70, 8, 4)
+	away := NewRectangle(0, 80, 16, 16)
+
+	for _, order := range []Space{
+		{box, far, near, middle, away},
+		{away, middle, near, far, box},
+	} {
+		space := NewSpace()
+		space.Add(order...)
+
+		collisions := space.ResolveAll(box, 100, 0)
+		assert.Len(t, collisions, 3)
+		assert.Equal(t, near, collisions[0].ShapeB)
+		assert.Equal(t, middle, collisions[1].ShapeB)
+		assert.Equal(t, far, collisions[2].ShapeB)
+		assert.True(t, collisions[0].Time < collisions[1].Time)
+
+		nearest := space.ResolveNearest(box, 100, 0)
+		assert.Equal(t, near, nearest.ShapeB)
+		assert.InDelta(t, 24, nearest.ResolveX, 1e-9)
+	}
+}
+
+func TestSpace_ResolveNearest_Ties(t *testing.T) {
+	box := NewRectangle(0, 0, 16, 16)
+	// Both are hit at the same time, but the contact with the first one is closer to the box's position.
+	left := NewRectangle(-8, 32, 16, 16)
+	right := NewRectangle(12, 32, 16, 16)
+
+	for _, order := range []Space{{left, right}, {right, left}} {
+		space := NewSpace()
+		space.Add(order...)
+		assert.Equal(t, left, space.ResolveNearest(box, 0, 32).ShapeB)
+	}
+
+	none := NewSpace().ResolveNearest(box, 0, 32)
+	assert.False(t, none.Colliding())
+}
+
+func TestSpace_Layers(t *testing.T) {
+	const (
+		layerSolid = 1 << iota
+		layerRamp
+		layerEnemy
+	)
+
+	space := NewSpace()
+
+	player := NewRectangle(0, 0, 16, 16)
+	player.SetMask(layerSolid | layerRamp)
+
+	wall := NewRectangle(32, 0, 16, 16)
+	wall.SetLayer(layerSolid)
+
+	enemy := NewRectangle(8, 0, 16, 16)
+	enemy.SetLayer(layerEnemy)
+
+	ramp := NewLine(0, 20, 16, 20)
+	ramp.SetLayer(layerRamp)
+
+	space.Add(player, wall, enemy, ramp)
+
+	assert.False(t, space.IsColliding(player), "the enemy isn't on a layer the player checks against")
+	assert.Equal(t, 0, space.GetCollidingShapes(player).Length())
+
+	res := space.Resolve(player, 32, 0)
+	assert.Equal(t, wall, res.ShapeB)
+	assert.InDelta(t, 16, res.ResolveX, 1e-9)
+
+	res = space.ResolveNearest(player, 0, 10)
+	assert.Equal(t, ramp, res.ShapeB)
+
+	res = Resolve(player, space, 32, 0)
+	assert.InDelta(t, 16, res.ResolveX, 1e-9)
+
+	player.SetMask(0)
+	assert.True(t, space.IsColliding(player), "a mask of 0 checks against everything")
+
+	hit := space.Raycast(-10, 8, 1, 0, 100, LayerFilter(layerEnemy|layerSolid))
+	assert.Equal(t, enemy, hit.Shape)
+
+	sub := NewSpace()
+	sub.Add(wall, enemy)
+	assert.Equal(t, uint64(layerSolid|layerEnemy), sub.GetLayer())
+}
@@ -0,0 +1,327 @@
+package resolv
+
+import "math"
+
+// Tolerance is the distance under which two surfaces are considered to be touching, rather than overlapping or apart, when
+// resolving movement. It absorbs the rounding errors of floating point numbers, so it should be well below the smallest
+// distance that matters in the units of the game; the default suits pixels as well as meters, but worlds with very large
+// coordinates (where rounding errors grow along with the coordinates) may need a larger one.
+//
+// Every query reads Tolerance, so it should only be set up front (like while the game is starting up), and never changed
+// while other goroutines could be resolving movement or testing for collisions; Shapes already in an IndexedSpace should
+// also be updated afterwards, as their bounding rectangles are grown by it.
+var Tolerance = 1e-9
+
+// epsilon is the margin used for comparisons that don't involve distances, like between the directions of normals.
+const epsilon = 1e-9
+
+// sweepHit describes the result of sweeping one Shape against another. Enter and exit are the fractions of the movement at
+// which the swept Shape starts and stops overlapping the other Shape (so if it started out overlapping, enter is negative),
+// normal is the direction pointing away from the surface that the swept Shape entered through, and shape is the Shape that
+// was hit (which is only different from the Shape swept against if that was a Space).
+type sweepHit struct {
+	enter, exit float64
+	normal      Point
+	shape       Shape
+	hullA       hull
+	hullB       hull
+}
+
+// blocks returns whether the hit would stop the swept Shape from moving by the full movement. This is the case if it
+// comes into contact with the other Shape on the way, or if it's embedded in the other Shape for the whole of the movement.
+// Shapes that start out overlapping but move out of each other aren't blocked, and neither are Shapes that only start out
+// touching something without any area (like a Line lying on a parallel Line). Starting out overlapping the other Shape by
+// no more than Tolerance counts as touching it.
+func (hit *sweepHit) blocks(delta Point) bool {
+	hit.touch(delta)
+	if hit.enter == hit.exit {
+		return hit.enter > 0 && hit.enter < 1
+	}
+	if hit.enter >= 0 {
+		return hit.enter < 1
+	}
+	return hit.exit > 1
+}
+
+// blocksOneWay returns whether the hit would stop the swept Shape, moving by the delta provided, when the Shape it's
+// swept against is a one-way Shape whose blocking side faces the direction provided. It only does if the swept Shape moves
+// against the direction and comes into contact with the blocking side on the way, having started out outside of the
+// one-way Shape; starting out overlapping it by no more than Tolerance counts as touching it.
+func (hit *sweepHit) blocksOneWay(dir, delta Point) bool {
+
+	if delta.dot(dir) >= 0 || hit.normal.dot(dir) <= 0 {
+		return false
+	}
+
+	if !hit.touch(delta) && hit.enter < 0 {
+		return false
+	}
+
+	if hit.enter == hit.exit {
+		return hit.enter > 0 && hit.enter < 1
+	}
+
+	return hit.enter < 1
+
+}
+
+// touch moves the start of the hit up to the start of the movement if the swept Shape, moving by the delta provided, starts
+// out overlapping the other Shape by no more than Tolerance, so that it counts as only touching it, and returns whether it
+// did.
+func (hit *sweepHit) touch(delta Point) bool {
+	if hit.enter >= 0 || hit.exit <= 0 || hit.enter*delta.dot(hit.normal) > Tolerance {
+		return false
+	}
+	hit.enter = 0
+	return true
+}
+
+// sweepShapes sweeps Shape a along the movement given by dx and dy against Shape b, returning the earliest hit that blocks
+// the movement, if there is one. Spaces are swept Shape by Shape. The bool return value is false if either Shape can't
+// be swept (because it's a custom Shape that can't be represented as a hull).
+func sweepShapes(a, b Shape, dx, dy float64) (sweepHit, bool, bool) {
+
+	if a == b {
+		return sweepHit{}, false, true
+	}
+
+	if spaceB, ok := b.(*Space); ok {
+		return earliestHit(*spaceB, func(member Shape) (sweepHit, bool, bool) {
+			if !canCollide(a, member) {
+				return sweepHit{}, false, true
+			}
+			return sweepShapes(a, member, dx, dy)
+		})
+	}
+
+	if spaceA, ok := a.(*Space); ok {
+		return earliestHit(*spaceA, func(member Shape) (sweepHit, bool, bool) {
+			return sweepShapes(member, b, dx, dy)
+		})
+	}
+
+	hullA, okA := toHull(a)
+	hullB, okB := toHull(b)
+
+	if !okA || !okB {
+		return sweepHit{}, false, false
+	}
+
+	if len(hullA.points) == 0 || len(hullB.points) == 0 {
+		return sweepHit{}, false, true
+	}
+
+	hit, ok := sweepHulls(hullA, hullB, Point{dx, dy})
+	if !ok {
+		return sweepHit{}, false, true
+	}
+
+	if dir, oneWay := isOneWay(b); oneWay {
+		if !hit.blocksOneWay(dir, Point{dx, dy}) {
+			return sweepHit{}, false, true
+		}
+	} else if !hit.blocks(Point{dx, dy}) {
+		return sweepHit{}, false, true
+	}
+
+	hit.shape = b
+	return hit, true, true
+
+}
+
+func earliestHit(shapes Space, sweep func(Shape) (sweepHit, bool, bool)) (sweepHit, bool, bool) {
+
+	earliest := sweepHit{}
+	found := false
+	supported := true
+
+	for _, shape := range shapes {
+		hit, ok, canSweep := sweep(shape)
+		supported = supported && canSweep
+		if ok && (!found || hit.enter < earliest.enter) {
+			earliest = hit
+			found = true
+		}
+	}
+
+	return earliest, found, supported
+
+}
+
+// sweepHulls returns the interval during which hull a, moving by the delta provided, overlaps hull b. The movement is
+// treated as if it extended infinitely in both directions, so the interval can lie outside of 0 to 1.
+//
+// It works by casting a ray from the origin along the delta against the Minkowski difference of the two hulls (b - a);
+// the hulls overlap exactly when a's offset from its starting position lies within the difference. The difference of two
+// convex hulls with radii is itself a convex hull (of the differences of their points) with the sum of their radii, which
+// is made up of the polygon itself, a rectangle extending out of each edge by the radius, and a circle at each corner.
+func sweepHulls(a, b hull, delta Point) (sweepHit, bool) {
+
+	diff := make([]Point, 0, len(a.points)*len(b.points))
+	for _, pb := range b.points {
+		for _, pa := range a.points {
+			diff = append(diff, pb.sub(pa))
+		}
+	}
+
+	points := convexHull(diff)
+	radius := a.radius + b.radius
+
+	hit := sweepHit{enter: math.Inf(1), exit: math.Inf(-1), hullA: a, hullB: b}
+	found := false
+
+	merge := func(enter, exit float64, normal Point, ok bool) {
+		if !ok {
+			return
+		}
+		if enter < hit.enter {
+			hit.enter = enter
+			hit.normal = normal
+		}
+		hit.exit = math.Max(hit.exit, exit)
+		found = true
+	}
+
+	if radius > 0 {
+		for _, p := range points {
+			merge(rayCircle(delta, p, radius))
+		}
+	}
+
+	switch {
+	case len(points) >= 3:
+		merge(rayPlanes(delta, polygonPlanes(points)))
+	case len(points) == 2 && radius == 0:
+		merge(raySegment(delta, points[0], points[1]))
+	}
+
+	if radius > 0 && len(points) >= 2 {
+		for i := range points {
+			start := points[i]
+			end := points[(i+1)%len(points)]
+			merge(rayPlanes(delta, edgePlanes(start, end, radius)))
+		}
+	}
+
+	return hit, found
+
+}
+
+// plane is a half-plane; points are inside of it if normal.dot(point) < offset.
+type plane struct {
+	normal Point
+	offset float64
+}
+
+func polygonPlanes(points []Point) []plane {
+	planes := make([]plane, 0, len(points))
+	for i, p := range points {
+		n := points[(i+1)%len(points)].sub(p).perp().normalized()
+		planes = append(planes, plane{n, n.dot(p)})
+	}
+	return planes
+}
+
+// edgePlanes returns the planes that make up the rectangle that extends out of the edge from start to end by the radius.
+func edgePlanes(start, end Point, radius float64) []plane {
+	tangent := end.sub(start).normalized()
+	n := tangent.perp()
+	return []plane{
+		{n, n.dot(start) + radius},
+		{n.scale(-1), -n.dot(start)},
+		{tangent.scale(-1), -tangent.dot(start)},
+		{tangent, tangent.dot(end)},
+	}
+}
+
+// rayPlanes clips the ray going from the origin along the delta against the convex area made by the planes provided,
+// returning the interval in which the ray lies within all of them and the normal of the plane it entered through.
+func rayPlanes(delta Point, planes []plane) (float64, float64, Point, bool) {
+
+	enter := math.Inf(-1)
+	exit := math.Inf(1)
+	normal := Point{}
+
+	for _, p := range planes {
+
+		den := p.normal.dot(delta)
+
+		if den == 0 {
+			// Moving parallel to the plane; if the origin is outside of it (or just touching it), the ray never enters.
+			if p.offset <= Tolerance {
+				return 0, 0, Point{}, false
+			}
+			continue
+		}
+
+		t := p.offset / den
+
+		if den < 0 {
+			if t > enter {
+				enter = t
+				normal = p.normal
+			}
+		} else if t < exit {
+			exit = t
+		}
+
+	}
+
+	if enter >= exit {
+		return 0, 0, Point{}, false
+	}
+
+	return enter, exit, normal, true
+
+}
+
+// rayCircle returns the interval in which the ray going from the origin along the delta lies within the circle provided.
+func rayCircle(delta, center Point, radius float64) (float64, float64, Point, bool) {
+
+	a := delta.dot(delta)
+	b := delta.dot(center)
+	c := center.dot(center) - radius*radius
+
+	disc := b*b - a*c
+
+	if disc <= 0 {
+		return 0, 0, Point{}, false
+	}
+
+	root := math.Sqrt(disc)
+	enter := (b - root) / a
+	exit := (b + root) / a
+
+	normal := delta.scale(enter).sub(center).normalized()
+
+	return enter, exit, normal, true
+
+}
+
+// raySegment returns the point at which the ray going from the origin along the delta crosses the segment from start to
+// end. As the segment has no area, the ray enters and exits it at the same time; rays running along the segment never
+// cross it.
+func raySegment(delta, start, end Point) (float64, float64, Point, bool) {
+
+	edge := end.sub(start)
+	den := delta.cross(edge)
+
+	if den == 0 {
+		return 0, 0, Point{}, false
+	}
+
+	t := start.cross(edge) / den
+	s := start.cross(delta) / den
+
+	if s < 0 || s > 1 {
+		return 0, 0, Point{}, false
+	}
+
+	normal := edge.perp().normalized()
+	if normal.dot(delta) > 0 {
+		normal = normal.scale(-1)
+	}
+
+	return t, t, normal, true
+
+}
@@ -0,0 +1,318 @@
+package resolv_test
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/SolarLune/resolv/resolv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve_Swept(t *testing.T) {
+
+	t.Run("Exact point of contact", func(t *testing.T) {
+		box := NewRectangle(0, 0, 16, 16)
+		wall := NewRectangle(20.5, -100, 8, 200)
+
+		res := Resolve(box, wall, 10, 0)
+		assert.True(t, res.Colliding())
+		assert.InDelta(t, 4.5, res.ResolveX, 1e-9)
+		assert.InDelta(t, 0.45, res.Time, 1e-9)
+		assert.Equal(t, -1.0, res.NormalX)
+		assert.False(t, res.Teleporting)
+	})
+
+	t.Run("Diagonal movement keeps its direction", func(t *testing.T) {
+		box := NewRectangle(0, 0, 16, 16)
+		floor := NewRectangle(-100, 20, 200, 8)
+
+		res := Resolve(box, floor, 3, 8)
+		assert.True(t, res.Colliding())
+		assert.InDelta(t, 1.5, res.ResolveX, 1e-9)
+		assert.InDelta(t, 4, res.ResolveY, 1e-9)
+		assert.Equal(t, -1.0, res.NormalY)
+	})
+
+	t.Run("Sub-pixel movement", func(t *testing.T) {
+		box := NewRectangle(0, 0, 16, 16)
+		wall := NewRectangle(16.2, 0, 8, 16)
+
+		res := Resolve(box, wall, 0.3, 0)
+		assert.True(t, res.Colliding())
+		assert.InDelta(t, 0.2, res.ResolveX, 1e-9)
+
+		res = Resolve(box, wall, 0.1, 0)
+		assert.False(t, res.Colliding())
+		assert.Equal(t, 0.1, res.ResolveX)
+	})
+
+	t.Run("No tunneling through thin Shapes", func(t *testing.T) {
+		bullet := NewCircle(0, 0, 1)
+		pane := NewLine(50, -10, 50, 10)
+
+		res := Resolve(bullet, pane, 100, 0)
+		assert.True(t, res.Colliding())
+		assert.InDelta(t, 49, res.ResolveX, 1e-9)
+		assert.Equal(t, -1.0, res.NormalX)
+	})
+
+	t.Run("Circle against a corner", func(t *testing.T) {
+		ball := NewCircle(0, 0, 5)
+		box := NewRectangle(8, 8, 16, 16)
+
+		res := Resolve(ball, box, 10, 10)
+		assert.True(t, res.Colliding())
+		// The ball touches the corner at 8, 8 after moving diagonally by 8 - 5/sqrt(2) on each axis.
+		assert.InDelta(t, 4.4644660940672, res.ResolveX, 1e-9)
+		assert.InDelta(t, 4.4644660940672, res.ResolveY, 1e-9)
+		assert.InDelta(t, -0.7071067811865, res.NormalX, 1e-9)
+		assert.Len(t, res.Contacts, 1)
+		assert.InDelta(t, 8, res.Contacts[0].X, 1e-9)
+	})
+
+	t.Run("Moving away from an overlap", func(t *testing.T) {
+		box := NewRectangle(0, 0, 16, 16)
+		other := NewRectangle(10, 0, 16, 16)
+
+		res := Resolve(box, other, -20, 0)
+		assert.False(t, res.Colliding())
+	})
+
+	t.Run("Embedded Shapes are pushed back", func(t *testing.T) {
+		box := NewRectangle(0, 0, 16, 16)
+		ramp := NewRectangle(-100, 10, 200, 100)
+
+		res := Resolve(box, ramp, 0, 4)
+		assert.True(t, res.Colliding())
+		assert.InDelta(t, -6, res.ResolveY, 1e-9)
+		assert.True(t, res.Teleporting)
+	})
+
+	t.Run("Sliding along a surface", func(t *testing.T) {
+		box := NewRectangle(0, 0, 16, 16)
+		floor := NewRectangle(-100, 16, 200, 8)
+
+		res := Resolve(box, floor, 20, 0)
+		assert.False(t, res.Colliding())
+
+		res = Resolve(box, floor, 0, 1)
+		assert.True(t, res.Colliding())
+		assert.Equal(t, 0.0, res.ResolveY)
+	})
+
+	t.Run("Space returns the Shape that was hit", func(t *testing.T) {
+		space := NewSpace()
+		near := NewRectangle(40, 0, 16, 16)
+		far := NewRectangle(80, 0, 16, 16)
+		space.Add(far, near)
+
+		box := NewRectangle(0, 0, 16, 16)
+		res := Resolve(box, space, 100, 0)
+		assert.True(t, res.Colliding())
+		assert.InDelta(t, 24, res.ResolveX, 1e-9)
+		assert.Equal(t, near, res.Contacts[0].Shape)
+	})
+}
+
+func TestResolve_OneWay(t *testing.T) {
+
+	platform := NewRectangle(0, 32, 64, 8)
+	platform.SetOneWay(0, -1)
+
+	t.Run("Landing from above", func(t *testing.T) {
+		box := NewRectangle(8, 0, 16, 16)
+		res := Resolve(box, platform, 0, 100)
+		assert.True(t, res.Colliding())
+		assert.Equal(t, 16.0, res.ResolveY)
+
+		// Standing on the platform, gravity doesn't pull the Shape through it.
+		box.Y = 16
+		res = Resolve(box, platform, 0, 1)
+		assert.True(t, res.Colliding())
+		assert.Equal(t, 0.0, res.ResolveY)
+	})
+
+	t.Run("Passing through", func(t *testing.T) {
+		// Jumping up from below.
+		box := NewRectangle(8, 48, 16, 16)
+		res := Resolve(box, platform, 0, -40)
+		assert.False(t, res.Colliding())
+
+		// Moving into its side.
+		box.SetXY(-20, 28)
+		res = Resolve(box, platform, 10, 0)
+		assert.False(t, res.Colliding())
+
+		// Already overlapping it, while falling.
+		box.SetXY(8, 20)
+		res = Resolve(box, platform, 0, 4)
+		assert.False(t, res.Colliding())
+		res = Resolve(box, platform, 0, 100)
+		assert.False(t, res.Colliding())
+	})
+
+	t.Run("Thin platforms and fast falls", func(t *testing.T) {
+		line := NewLine(0, 32, 64, 32)
+		line.SetOneWay(0, -1)
+		box := NewRectangle(8, 0, 16, 16)
+
+		res := Resolve(box, line, 0, 1000)
+		assert.True(t, res.Colliding())
+		assert.Equal(t, 16.0, res.ResolveY)
+	})
+
+	t.Run("Space", func(t *testing.T) {
+		space := NewSpace()
+		floor := NewRectangle(0, 100, 64, 8)
+		space.Add(platform, floor)
+
+		box := NewRectangle(8, 40, 16, 16)
+		res := space.ResolveNearest(box, 0, 100)
+		assert.Equal(t, floor, res.ShapeB)
+
+		box.Y = 0
+		res = space.Resolve(box, 0, 100)
+		assert.Equal(t, platform, res.ShapeB)
+
+		assert.True(t, platform.IsColliding(NewRectangle(8, 30, 16, 16)), "overlap tests aren't affected")
+	})
+
+}
+
+func TestResolveSlide(t *testing.T) {
+
+	t.Run("Along the floor", func(t *testing.T) {
+		space := NewSpace()
+		floor := NewRectangle(-100, 16, 200, 16)
+		box := NewRectangle(0, 0, 16, 16)
+		space.Add(floor, box)
+
+		res := space.ResolveSlide(box, 5, 3)
+		assert.True(t, res.Colliding())
+		assert.Equal(t, floor, res.ShapeB)
+		assert.Equal(t, 0.0, res.Time)
+		assert.Equal(t, 5.0, res.ResolveX)
+		assert.Equal(t, 0.0, res.ResolveY)
+		assert.Equal(t, 0.0, box.X, "the Shape isn't moved")
+	})
+
+	t.Run("Up and down ramps", func(t *testing.T) {
+		// A 45 degree ramp rising to the right, with the box's bottom right corner resting on it.
+		ramp := NewLine(0, 100, 100, 0)
+		box := NewRectangle(34, 34, 16, 16)
+
+		// Walking right and falling, the box slides up along the ramp, and the vertical part of the movement is
+		// projected along it too.
+		res := ResolveSlide(box, ramp, 4, 2)
+		assert.True(t, res.Colliding())
+		assert.Equal(t, ramp, res.ShapeB)
+		assert.InDelta(t, 1.0, res.ResolveX, 1e-9)
+		assert.InDelta(t, -1.0, res.ResolveY, 1e-9)
+		assert.InDelta(t, -math.Sqrt2/2, res.NormalX, 1e-9)
+		assert.InDelta(t, -math.Sqrt2/2, res.NormalY, 1e-9)
+
+		// Walking left and falling faster than the ramp drops, the box follows it down.
+		res = ResolveSlide(box, ramp, -2, 4)
+		assert.True(t, res.Colliding())
+		assert.InDelta(t, -3.0, res.ResolveX, 1e-9)
+		assert.InDelta(t, 3.0, res.ResolveY, 1e-9)
+	})
+
+	t.Run("Corners", func(t *testing.T) {
+		space := NewSpace()
+		box := NewRectangle(0, 0, 16, 16)
+		space.Add(box, NewRectangle(-100, 16, 200, 16), NewRectangle(20, -100, 16, 200))
+
+		res := space.ResolveSlide(box, 10, 10)
+		assert.True(t, res.Colliding())
+		assert.Equal(t, 4.0, res.ResolveX)
+		assert.Equal(t, 0.0, res.ResolveY)
+	})
+
+	t.Run("Nothing in the way", func(t *testing.T) {
+		box := NewRectangle(0, 0, 16, 16)
+		res := ResolveSlide(box, NewRectangle(100, 100, 16, 16), 10, 10)
+		assert.False(t, res.Colliding())
+		assert.Equal(t, 10.0, res.ResolveX)
+		assert.Equal(t, 10.0, res.ResolveY)
+	})
+
+}
+
+func TestResolve_Scale(t *testing.T) {
+
+	// The same scene of a box moving diagonally onto a floor, at different scales and positions.
+	tests := []struct {
+		name             string
+		scale            float64
+		offsetX, offsetY float64
+	}{
+		{"Pixels", 1, 0, 0},
+		{"Meters", 0.01, 0, 0},
+		{"Tiny", 1e-6, 0, 0},
+		{"Large", 1000, 0, 0},
+		{"Far from the origin", 1, 1e6, 1e6},
+		{"Negative coordinates", 1, -5000, -3000},
+		{"Negative and small", 0.01, -50, -30},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := test.scale
+			box := NewRectangle(test.offsetX, test.offsetY, 16*s, 16*s)
+			floor := NewRectangle(test.offsetX-100*s, test.offsetY+20*s, 200*s, 8*s)
+			tolerance := 1e-9 * math.Max(s, math.Max(math.Abs(test.offsetX), math.Abs(test.offsetY)))
+
+			res := Resolve(box, floor, 3*s, 8*s)
+			assert.True(t, res.Colliding())
+			assert.InDelta(t, 0.5, res.Time, 1e-9)
+			assert.InDelta(t, 1.5*s, res.ResolveX, tolerance)
+			assert.InDelta(t, 4*s, res.ResolveY, tolerance)
+			assert.Equal(t, -1.0, res.NormalY)
+
+			// Creeping up on the floor by a tenth of the gap at a time stops exactly on it.
+			moved := 0.0
+			for i := 0; i < 20; i++ {
+				res = Resolve(box, floor, 0, 0.4*s)
+				box.Move(0, res.ResolveY)
+				moved += res.ResolveY
+			}
+			assert.InDelta(t, 4*s, moved, tolerance)
+
+			// Standing on the floor, the box moves freely along it, but not into it.
+			res = Resolve(box, floor, 0.001*s, 0)
+			assert.False(t, res.Colliding())
+			assert.Equal(t, 0.001*s, res.ResolveX)
+
+			res = Resolve(box, floor, 0, 0.001*s)
+			assert.True(t, res.Colliding())
+			assert.InDelta(t, 0, res.ResolveY, tolerance)
+		})
+	}
+
+}
+
+func TestResolve_Tolerance(t *testing.T) {
+
+	box := NewRectangle(0, 0, 16, 16)
+	floor := NewRectangle(-100, 16-1e-6, 200, 8)
+
+	// By default, a box sunk into the floor by a micrometer is overlapping it, so moving down pushes it back out.
+	res := Resolve(box, floor, 0, 1)
+	assert.True(t, res.Colliding())
+	assert.InDelta(t, -1e-6, res.ResolveY, 1e-9)
+	assert.Less(t, res.Time, 0.0)
+
+	defer func(tolerance float64) { Tolerance = tolerance }(Tolerance)
+	Tolerance = 1e-3
+
+	// With a coarser tolerance, it's only touching the floor.
+	res = Resolve(box, floor, 0, 1)
+	assert.True(t, res.Colliding())
+	assert.Equal(t, 0.0, res.ResolveY)
+	assert.Equal(t, 0.0, res.Time)
+
+	res = Resolve(box, floor, 1, 0)
+	assert.False(t, res.Colliding())
+
+}
@@ -5,86 +5,186 @@ import (
 )
 
 // Resolve attempts to move the checking Shape with the specified X and Y values, returning a Collision object
-// if it collides with the specified other Shape. The deltaX and deltaY arguments are the movement displacement
-// in pixels. For platformers in particular, you would probably want to resolve on the X and Y axes separately.
+// if it collides with the specified other Shape. The deltaX and deltaY arguments are the movement displacement, in
+// whatever units the Shapes use; there's no minimum step, so fractional movements (like sub-pixel movements, or movements
+// in meters) resolve just as exactly as whole ones. Surfaces closer than Tolerance count as touching. For platformers in
+// particular, you would probably want to resolve on the X and Y axes separately.
+//
+// The movement is tested continuously (the checking Shape is swept along the delta, rather than just tested at its
+// destination), so ResolveX and ResolveY are the exact displacement at which the checking Shape first touches the other
+// Shape, and fast-moving Shapes can't pass through thin ones. If the checking Shape is already overlapping the other Shape
+// and would still be overlapping it at the end of the movement, ResolveX and ResolveY move it backwards along the delta
+// until it no longer overlaps. Shapes that can't be swept (custom Shape types) fall back to narrowing down the point of
+// contact with WouldBeColliding().
 func Resolve(firstShape Shape, other Shape, deltaX, deltaY float64) Collision {
 
 	out := Collision{}
 	out.ResolveX = deltaX
 	out.ResolveY = deltaY
+	out.Time = 1
 	out.ShapeA = firstShape
 
 	if deltaX == 0 && deltaY == 0 {
 		return out
 	}
 
-	x := deltaX
-	y := deltaY
+	hit, colliding, supported := sweepShapes(firstShape, other, deltaX, deltaY)
 
-	primeX := true
-	slope := 0.0
+	if !supported {
+		return resolveByBisection(firstShape, other, deltaX, deltaY)
+	}
 
-	if math.Abs(float64(deltaY)) > math.Abs(float64(deltaX)) {
-		primeX = false
-		if deltaY != 0 && deltaX != 0 {
-			slope = deltaX / deltaY
-		}
-	} else if deltaY != 0 && deltaX != 0 {
-		slope = deltaY / deltaX
+	if !colliding {
+		return out
 	}
 
-	for true {
+	out.ShapeB = other
+	out.Time = hit.enter
+	out.ResolveX = deltaX * hit.enter
+	out.ResolveY = deltaY * hit.enter
+	out.NormalX = hit.normal.X
+	out.NormalY = hit.normal.Y
+	out.Depth = -(1 - hit.enter) * hit.normal.dot(Point{deltaX, deltaY})
+
+	offset := Point{out.ResolveX, out.ResolveY}
+	moved := hull{points: make([]Point, len(hit.hullA.points)), radius: hit.hullA.radius}
+	for i, p := range hit.hullA.points {
+		moved.points[i] = p.add(offset)
+	}
 
-		if firstShape.WouldBeColliding(other, out.ResolveX, out.ResolveY) {
+	for _, p := range contactPoints(moved, hit.hullB, hit.normal) {
+		out.Contacts = append(out.Contacts, IntersectionPoint{X: p.X, Y: p.Y, Shape: hit.shape})
+	}
 
-			if primeX {
+	if math.Abs(float64(deltaX-out.ResolveX)) > math.Abs(float64(deltaX)*1.5) || math.Abs(float64(deltaY-out.ResolveY)) > math.Abs(float64(deltaY)*1.5) {
+		out.Teleporting = true
+	}
 
-				if deltaX > 0 {
-					x--
-				} else if deltaX < 0 {
-					x++
-				}
+	return out
 
-				if deltaY > 0 {
-					y -= slope
-				} else if deltaY < 0 {
-					y += slope
-				}
+}
 
-			} else {
+// maxBisections is the most times that resolveByBisection() halves the range; past that, the halves can't get any smaller
+// as floating point numbers.
+const maxBisections = 64
 
-				if deltaY > 0 {
-					y--
-				} else if deltaY < 0 {
-					y++
-				}
+// resolveByBisection resolves the movement of the checking Shape by repeatedly halving the range in which the point of
+// contact could lie, using WouldBeColliding(), until it's narrowed down to within Tolerance (or as far as floating point
+// numbers allow). It's used for Shapes that can't be swept analytically.
+func resolveByBisection(firstShape Shape, other Shape, deltaX, deltaY float64) Collision {
 
-				if deltaX > 0 {
-					x -= slope
-				} else if deltaX < 0 {
-					x += slope
-				}
+	out := Collision{ResolveX: deltaX, ResolveY: deltaY, Time: 1, ShapeA: firstShape}
 
-			}
+	if !firstShape.WouldBeColliding(other, deltaX, deltaY) {
+		return out
+	}
 
-			out.ResolveX = x
-			out.ResolveY = y
-			out.ShapeB = other
+	free, blocked := 0.0, 1.0
+	length := Distance(0, 0, deltaX, deltaY)
 
+	for i := 0; i < maxBisections && (blocked-free)*length > Tolerance; i++ {
+		mid := (free + blocked) / 2
+		if firstShape.WouldBeColliding(other, deltaX*mid, deltaY*mid) {
+			blocked = mid
 		} else {
+			free = mid
+		}
+	}
+
+	out.ShapeB = other
+	out.Time = free
+	out.ResolveX = deltaX * free
+	out.ResolveY = deltaY * free
+
+	return out
+
+}
+
+// maxSlides is the number of surfaces that ResolveSlide() slides along before giving up on the rest of the movement.
+const maxSlides = 4
+
+// ResolveSlide attempts to move the checking Shape with the specified X and Y values, like Resolve(), but instead of
+// stopping at the first Shape it runs into, it slides along it; the part of the movement left over after coming into
+// contact is projected along the contact surface (so along a Line's direction, for a Line), and resolved again. This lets a
+// Shape moving both sideways and down (like a character walking under gravity) move smoothly along floors and up and down
+// ramps of any angle, without resolving each axis separately. Sliding stops after running into a few surfaces in a row,
+// like in a corner.
+//
+// ResolveX and ResolveY of the returned Collision are the total displacement, including the sliding. Time is the fraction
+// of the movement completed before the first contact, and the other fields describe the last contact, where the Shape
+// came to touch the last surface it slid along. If the Shape doesn't run into anything, the Collision isn't Colliding().
+// Note that a Shape standing on a ramp slides down it if the movement has a downward part; CharacterController can be used
+// to avoid that.
+func ResolveSlide(firstShape Shape, other Shape, deltaX, deltaY float64) Collision {
+	return resolveSlide(firstShape, func(dx, dy float64) Collision {
+		return Resolve(firstShape, other, dx, dy)
+	}, deltaX, deltaY)
+}
+
+// resolveSlide slides the Shape along the surfaces that the resolve function provided runs it into.
+func resolveSlide(firstShape Shape, resolve func(dx, dy float64) Collision, deltaX, deltaY float64) Collision {
+
+	out := Collision{ResolveX: deltaX, ResolveY: deltaY, Time: 1, ShapeA: firstShape}
+
+	// The Shape is moved along as it slides, so that each resolution starts from where the previous one left off, and
+	// put back once done.
+	applied := Point{}
+	defer func() { firstShape.Move(-applied.X, -applied.Y) }()
+
+	moved := Point{}
+	remaining := Point{deltaX, deltaY}
+
+	for i := 0; i < maxSlides; i++ {
+
+		if remaining.length() <= Tolerance {
+			remaining = Point{}
 			break
 		}
 
-	}
+		res := resolve(remaining.X, remaining.Y)
+
+		if !res.Colliding() {
+			break
+		}
+
+		if i == 0 {
+			out.Time = res.Time
+		}
+
+		step := Point{res.ResolveX, res.ResolveY}
+		firstShape.Move(step.X, step.Y)
+		applied = applied.add(step)
+		moved = moved.add(step)
+
+		remaining = slide(remaining.sub(step), Point{res.NormalX, res.NormalY})
+
+		out.ShapeB = res.ShapeB
+		out.NormalX, out.NormalY = res.NormalX, res.NormalY
+		out.Depth = res.Depth
+		out.Contacts = res.Contacts
+
+		if i == maxSlides-1 {
+			remaining = Point{}
+		}
 
-	if math.Abs(float64(deltaX-out.ResolveX)) > math.Abs(float64(deltaX)*1.5) || math.Abs(float64(deltaY-out.ResolveY)) > math.Abs(float64(deltaY)*1.5) {
-		out.Teleporting = true
 	}
 
+	moved = moved.add(remaining)
+	out.ResolveX, out.ResolveY = moved.X, moved.Y
+
 	return out
 
 }
 
+// slide returns the part of the movement that runs along the surface with the normal provided, removing the part that
+// runs into it.
+func slide(movement, normal Point) Point {
+	if into := movement.dot(normal); into < 0 {
+		return movement.sub(normal.scale(into))
+	}
+	return movement
+}
+
 // Distance returns the distance from one pair of X and Y values to another.
 func Distance(x, y, x2, y2 float64) float64 {
 